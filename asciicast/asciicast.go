@@ -0,0 +1,253 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package asciicast implements reading and writing terminal sessions in the asciicast v2 format
+// (see https://docs.asciinema.org/manual/asciicast/v2/).
+package asciicast
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Version is the supported asciicast version.
+const Version = 2
+
+// EventType is the type of an Event.
+type EventType string
+
+const (
+	// EventTypeOutput is the type of data written to the terminal.
+	EventTypeOutput EventType = "o"
+	// EventTypeInput is the type of data read from the terminal.
+	EventTypeInput EventType = "i"
+	// EventTypeResize is the type of a terminal resize. Its data is in the form '<width>x<height>'.
+	EventTypeResize EventType = "r"
+)
+
+const (
+	// DefaultWidth is the terminal width recorded if the width is unknown.
+	DefaultWidth = 80
+	// DefaultHeight is the terminal height recorded if the height is unknown.
+	DefaultHeight = 24
+)
+
+// Header is the first line of an asciicast.
+type Header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Command   string            `json:"command,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Event is a single event of an asciicast.
+type Event struct {
+	// Time is the time since the start of the recording.
+	Time time.Duration
+	Type EventType
+	Data string
+}
+
+// MarshalJSON encodes the event as '[<seconds>, <type>, <data>]'.
+func (e Event) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]any{e.Time.Seconds(), e.Type, e.Data})
+}
+
+// UnmarshalJSON decodes an event encoded as '[<seconds>, <type>, <data>]'.
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var (
+		seconds float64
+		fields  = []any{&seconds, &e.Type, &e.Data}
+		n       = len(fields)
+	)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if len(fields) != n {
+		return fmt.Errorf("expected %d fields but got %d", n, len(fields))
+	}
+	e.Time = time.Duration(seconds * float64(time.Second))
+	return nil
+}
+
+// Writer writes an asciicast. It is safe for concurrent use.
+type Writer struct {
+	mu      sync.Mutex
+	enc     *json.Encoder
+	start   time.Time
+	pending map[EventType][]byte
+}
+
+// NewWriter writes the given header to w and returns a Writer for the events.
+// If unset, the version, size and timestamp of the header are defaulted.
+func NewWriter(w io.Writer, header Header) (*Writer, error) {
+	start := time.Now()
+	if header.Version == 0 {
+		header.Version = Version
+	}
+	if header.Width == 0 {
+		header.Width = DefaultWidth
+	}
+	if header.Height == 0 {
+		header.Height = DefaultHeight
+	}
+	if header.Timestamp == 0 {
+		header.Timestamp = start.Unix()
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(header); err != nil {
+		return nil, fmt.Errorf("error writing header: %w", err)
+	}
+	return &Writer{
+		enc:     enc,
+		start:   start,
+		pending: make(map[EventType][]byte),
+	}, nil
+}
+
+// WriteEvent records an event of the given type with the given data.
+// Data ending in an incomplete UTF-8 sequence is completed by the next event of the same type.
+func (w *Writer) WriteEvent(typ EventType, data []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	data = append(w.pending[typ], data...)
+	data, w.pending[typ] = splitIncompleteRune(data)
+	if len(data) == 0 {
+		return nil
+	}
+
+	return w.enc.Encode(Event{
+		Time: time.Since(w.start),
+		Type: typ,
+		Data: string(data),
+	})
+}
+
+// Flush records the incomplete UTF-8 sequences buffered by WriteEvent, replacing them with U+FFFD.
+// It has to be called at the end of the recording.
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, typ := range []EventType{EventTypeOutput, EventTypeInput} {
+		data := w.pending[typ]
+		if len(data) == 0 {
+			continue
+		}
+		delete(w.pending, typ)
+
+		if err := w.enc.Encode(Event{
+			Time: time.Since(w.start),
+			Type: typ,
+			Data: strings.ToValidUTF8(string(data), string(utf8.RuneError)),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteResize records a terminal resize to the given size.
+func (w *Writer) WriteResize(width, height int) error {
+	return w.WriteEvent(EventTypeResize, []byte(fmt.Sprintf("%dx%d", width, height)))
+}
+
+// EventWriter returns an io.Writer recording each write as an event of the given type.
+func (w *Writer) EventWriter(typ EventType) io.Writer {
+	return eventWriter{w: w, typ: typ}
+}
+
+type eventWriter struct {
+	w   *Writer
+	typ EventType
+}
+
+func (w eventWriter) Write(p []byte) (int, error) {
+	if err := w.w.WriteEvent(w.typ, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// splitIncompleteRune splits off an incomplete UTF-8 sequence at the end of p.
+func splitIncompleteRune(p []byte) (complete, rest []byte) {
+	for i := len(p) - 1; i >= 0 && i >= len(p)-utf8.UTFMax; i-- {
+		if !utf8.RuneStart(p[i]) {
+			continue
+		}
+		if utf8.FullRune(p[i:]) {
+			return p, nil
+		}
+		return p[:i], append([]byte(nil), p[i:]...)
+	}
+	return p, nil
+}
+
+// Reader reads an asciicast.
+type Reader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// NewReader reads the header from r and returns a Reader for the events.
+func NewReader(r io.Reader) (*Header, *Reader, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	rd := &Reader{scanner: scanner}
+
+	line, err := rd.nextLine()
+	if err != nil {
+		if err == io.EOF {
+			return nil, nil, fmt.Errorf("missing header")
+		}
+		return nil, nil, err
+	}
+
+	header := &Header{}
+	if err := json.Unmarshal(line, header); err != nil {
+		return nil, nil, fmt.Errorf("error decoding header: %w", err)
+	}
+	if header.Version != Version {
+		return nil, nil, fmt.Errorf("unsupported asciicast version %d", header.Version)
+	}
+	return header, rd, nil
+}
+
+// Next returns the next event. It returns io.EOF if there are no more events.
+func (r *Reader) Next() (*Event, error) {
+	line, err := r.nextLine()
+	if err != nil {
+		return nil, err
+	}
+
+	event := &Event{}
+	if err := json.Unmarshal(line, event); err != nil {
+		return nil, fmt.Errorf("error decoding event in line %d: %w", r.line, err)
+	}
+	return event, nil
+}
+
+func (r *Reader) nextLine() ([]byte, error) {
+	for r.scanner.Scan() {
+		r.line++
+		if line := r.scanner.Bytes(); len(line) > 0 {
+			return line, nil
+		}
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
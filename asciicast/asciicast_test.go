@@ -0,0 +1,263 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package asciicast
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readAll reads the header and all events of the given asciicast.
+func readAll(t *testing.T, data string) (*Header, []Event) {
+	t.Helper()
+
+	header, r, err := NewReader(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("error reading header: %v", err)
+	}
+
+	var events []Event
+	for {
+		event, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return header, events
+		}
+		if err != nil {
+			t.Fatalf("error reading event: %v", err)
+		}
+		events = append(events, *event)
+	}
+}
+
+// eventData returns the types and data of the given events.
+func eventData(events []Event) [][2]string {
+	var res [][2]string
+	for _, event := range events {
+		res = append(res, [2]string{string(event.Type), event.Data})
+	}
+	return res
+}
+
+func TestRoundTrip(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(buf, Header{
+		Width:   120,
+		Height:  40,
+		Command: "exec my-machine",
+		Env:     map[string]string{"TERM": "xterm-256color"},
+	})
+	if err != nil {
+		t.Fatalf("error creating writer: %v", err)
+	}
+
+	for _, write := range []func() error{
+		func() error { return w.WriteEvent(EventTypeOutput, []byte("login: ")) },
+		func() error { return w.WriteEvent(EventTypeInput, []byte("root\r")) },
+		func() error { return w.WriteResize(100, 30) },
+		func() error {
+			_, err := w.EventWriter(EventTypeOutput).Write([]byte("\"quoted\" <html> \x1b[0m"))
+			return err
+		},
+	} {
+		if err := write(); err != nil {
+			t.Fatalf("error writing event: %v", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("error flushing: %v", err)
+	}
+
+	header, events := readAll(t, buf.String())
+	if header.Version != Version || header.Width != 120 || header.Height != 40 || header.Timestamp == 0 {
+		t.Errorf("unexpected header %+v", header)
+	}
+	if header.Command != "exec my-machine" || header.Env["TERM"] != "xterm-256color" {
+		t.Errorf("unexpected header command or env %+v", header)
+	}
+
+	expected := [][2]string{
+		{"o", "login: "},
+		{"i", "root\r"},
+		{"r", "100x30"},
+		{"o", "\"quoted\" <html> \x1b[0m"},
+	}
+	if actual := eventData(events); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected events %q, got %q", expected, actual)
+	}
+	for i := 1; i < len(events); i++ {
+		if events[i].Time < events[i-1].Time {
+			t.Errorf("event %d time %s is before previous event time %s", i, events[i].Time, events[i-1].Time)
+		}
+	}
+}
+
+func TestNewWriterDefaults(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if _, err := NewWriter(buf, Header{}); err != nil {
+		t.Fatalf("error creating writer: %v", err)
+	}
+
+	header, events := readAll(t, buf.String())
+	if header.Version != Version || header.Width != DefaultWidth || header.Height != DefaultHeight || header.Timestamp == 0 {
+		t.Errorf("expected defaulted header, got %+v", header)
+	}
+	if len(events) != 0 {
+		t.Errorf("expected no events, got %d", len(events))
+	}
+}
+
+func TestWriteEventSplitRunes(t *testing.T) {
+	euro := []byte("€") // 3 bytes
+
+	for _, tc := range []struct {
+		name     string
+		writes   [][]byte
+		expected [][2]string
+	}{
+		{
+			name:     "complete runes",
+			writes:   [][]byte{[]byte("a€"), []byte("b")},
+			expected: [][2]string{{"o", "a€"}, {"o", "b"}},
+		},
+		{
+			name:     "rune split across two writes",
+			writes:   [][]byte{append([]byte("a"), euro[:2]...), append(euro[2:], 'b')},
+			expected: [][2]string{{"o", "a"}, {"o", "€b"}},
+		},
+		{
+			name:     "rune split across three writes",
+			writes:   [][]byte{euro[:1], euro[1:2], euro[2:]},
+			expected: [][2]string{{"o", "€"}},
+		},
+		{
+			name:     "incomplete rune flushed",
+			writes:   [][]byte{append([]byte("a"), euro[:2]...)},
+			expected: [][2]string{{"o", "a"}, {"o", "�"}},
+		},
+		{
+			name:     "invalid bytes",
+			writes:   [][]byte{{'a', 0xff, 'b'}},
+			expected: [][2]string{{"o", "a�b"}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w, err := NewWriter(buf, Header{})
+			if err != nil {
+				t.Fatalf("error creating writer: %v", err)
+			}
+			for _, data := range tc.writes {
+				if err := w.WriteEvent(EventTypeOutput, data); err != nil {
+					t.Fatalf("error writing event: %v", err)
+				}
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatalf("error flushing: %v", err)
+			}
+
+			_, events := readAll(t, buf.String())
+			if actual := eventData(events); !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected events %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSplitIncompleteRune(t *testing.T) {
+	euro := []byte("€")
+	emoji := []byte("😀") // 4 bytes
+
+	for _, tc := range []struct {
+		name             string
+		data             []byte
+		expectedComplete []byte
+		expectedRest     []byte
+	}{
+		{name: "empty"},
+		{name: "ascii", data: []byte("abc"), expectedComplete: []byte("abc")},
+		{name: "complete multibyte rune", data: euro, expectedComplete: euro},
+		{name: "incomplete 3-byte rune", data: append([]byte("a"), euro[:2]...), expectedComplete: []byte("a"), expectedRest: euro[:2]},
+		{name: "incomplete 4-byte rune", data: emoji[:3], expectedComplete: []byte{}, expectedRest: emoji[:3]},
+		{name: "trailing continuation byte without start", data: []byte{'a', 0x80}, expectedComplete: []byte{'a', 0x80}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			complete, rest := splitIncompleteRune(tc.data)
+			if !bytes.Equal(complete, tc.expectedComplete) {
+				t.Errorf("expected complete %q, got %q", tc.expectedComplete, complete)
+			}
+			if !bytes.Equal(rest, tc.expectedRest) {
+				t.Errorf("expected rest %q, got %q", tc.expectedRest, rest)
+			}
+		})
+	}
+}
+
+func TestEventJSON(t *testing.T) {
+	data, err := json.Marshal(Event{Time: 1500 * time.Millisecond, Type: EventTypeOutput, Data: "hi"})
+	if err != nil {
+		t.Fatalf("error marshaling event: %v", err)
+	}
+	if string(data) != `[1.5,"o","hi"]` {
+		t.Errorf("unexpected event encoding %s", data)
+	}
+
+	event := &Event{}
+	if err := json.Unmarshal([]byte(`[2.25, "r", "80x24"]`), event); err != nil {
+		t.Fatalf("error unmarshaling event: %v", err)
+	}
+	if *event != (Event{Time: 2250 * time.Millisecond, Type: EventTypeResize, Data: "80x24"}) {
+		t.Errorf("unexpected event %+v", event)
+	}
+
+	for _, invalid := range []string{`[1, "o"]`, `[1, "o", "a", "b"]`, `{"time": 1}`, `["1", "o", "a"]`} {
+		if err := json.Unmarshal([]byte(invalid), &Event{}); err == nil {
+			t.Errorf("expected an error decoding %s", invalid)
+		}
+	}
+}
+
+func TestReader(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		data        string
+		expectError string
+	}{
+		{
+			name:        "missing header",
+			data:        "\n\n",
+			expectError: "missing header",
+		},
+		{
+			name:        "invalid header",
+			data:        "not json\n",
+			expectError: "error decoding header",
+		},
+		{
+			name:        "unsupported version",
+			data:        `{"version": 1, "width": 80, "height": 24}` + "\n",
+			expectError: "unsupported asciicast version 1",
+		},
+		{
+			name:        "invalid event",
+			data:        `{"version": 2, "width": 80, "height": 24}` + "\n\n" + `[1, "o"]` + "\n",
+			expectError: "line 3",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, r, err := NewReader(strings.NewReader(tc.data))
+			if err == nil {
+				_, err = r.Next()
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expectError) {
+				t.Errorf("expected an error containing %q, got %v", tc.expectError, err)
+			}
+		})
+	}
+}
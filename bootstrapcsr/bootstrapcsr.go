@@ -0,0 +1,224 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrapcsr
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ironcore-dev/kubectl-ironcore/bootstraptoken"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	bootstrapapi "k8s.io/cluster-bootstrap/token/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	certificateRequestPEMBlockType = "CERTIFICATE REQUEST"
+
+	// ApprovedReason is the reason of the approval conditions set by Approve.
+	ApprovedReason = "KubectlIroncoreApprove"
+	// DeniedReason is the reason of the denial conditions set by Deny.
+	DeniedReason = "KubectlIroncoreDeny"
+)
+
+// RequiredUsages are the usages client certificates of ironcore components have to request.
+var RequiredUsages = sets.New[certificatesv1.KeyUsage](
+	certificatesv1.UsageDigitalSignature,
+	certificatesv1.UsageKeyEncipherment,
+	certificatesv1.UsageClientAuth,
+)
+
+// Request is a validated certificate signing request of a component registering with a bootstrap token.
+type Request struct {
+	// Type is the type of the bootstrap token the request was made with.
+	Type bootstraptoken.Type
+	// TokenID is the id of the bootstrap token the request was made with.
+	TokenID string
+	// PoolName is the name of the pool the component registers.
+	PoolName string
+	// PoolScoped indicates that the bootstrap token was scoped to the pool (see bootstraptoken.PoolGroup).
+	PoolScoped bool
+}
+
+// IsPending reports whether the given certificate signing request is neither approved, denied nor failed.
+func IsPending(csr *certificatesv1.CertificateSigningRequest) bool {
+	for _, cond := range csr.Status.Conditions {
+		switch cond.Type {
+		case certificatesv1.CertificateApproved, certificatesv1.CertificateDenied, certificatesv1.CertificateFailed:
+			return false
+		}
+	}
+	return true
+}
+
+// IsFinished reports whether the certificate of the given certificate signing request was issued
+// or whether the request was denied or failed.
+func IsFinished(csr *certificatesv1.CertificateSigningRequest) bool {
+	if len(csr.Status.Certificate) > 0 {
+		return true
+	}
+	for _, cond := range csr.Status.Conditions {
+		switch cond.Type {
+		case certificatesv1.CertificateDenied, certificatesv1.CertificateFailed:
+			return true
+		}
+	}
+	return false
+}
+
+// IsBootstrap reports whether the given certificate signing request was made by a user
+// authenticated with a bootstrap token.
+func IsBootstrap(csr *certificatesv1.CertificateSigningRequest) bool {
+	return strings.HasPrefix(csr.Spec.Username, bootstrapapi.BootstrapUserPrefix) &&
+		slices.Contains(csr.Spec.Groups, bootstrapapi.BootstrapDefaultGroup)
+}
+
+// IsIroncore reports whether the given certificate signing request was made by a user authenticated with
+// a bootstrap token whose groups map to an ironcore token type. Other bootstrap token users, e.g. kubelets
+// joining with kubeadm bootstrap tokens, are not considered.
+func IsIroncore(csr *certificatesv1.CertificateSigningRequest) bool {
+	return IsBootstrap(csr) && len(bootstraptoken.TypesOfGroups(csr.Spec.Groups)) > 0
+}
+
+// ParseCertificateRequest parses the PEM-encoded x509 certificate request of the given certificate signing request.
+func ParseCertificateRequest(csr *certificatesv1.CertificateSigningRequest) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode(csr.Spec.Request)
+	if block == nil || block.Type != certificateRequestPEMBlockType {
+		return nil, fmt.Errorf("pem block type must be %s", certificateRequestPEMBlockType)
+	}
+	return x509.ParseCertificateRequest(block.Bytes)
+}
+
+// Validate validates the given certificate signing request was made with a bootstrap token of a single type
+// and conforms to the certificate conventions of that type. The returned error describes why it does not.
+func Validate(csr *certificatesv1.CertificateSigningRequest) (*Request, error) {
+	if !IsBootstrap(csr) {
+		return nil, fmt.Errorf("requester %s is not a bootstrap token user", csr.Spec.Username)
+	}
+
+	var types []bootstraptoken.Type
+	for _, typ := range bootstraptoken.TypesOfGroups(csr.Spec.Groups) {
+		if organization, _, _ := bootstraptoken.CertificateConvention(typ); organization != "" {
+			types = append(types, typ)
+		}
+	}
+	switch len(types) {
+	case 0:
+		return nil, fmt.Errorf("requester groups do not match any token type with certificate conventions")
+	case 1:
+	default:
+		return nil, fmt.Errorf("requester groups match multiple token types %v", types)
+	}
+	typ := types[0]
+	organization, userNamePrefix, _ := bootstraptoken.CertificateConvention(typ)
+
+	if csr.Spec.SignerName != certificatesv1.KubeAPIServerClientSignerName {
+		return nil, fmt.Errorf("signer is %s instead of %s", csr.Spec.SignerName, certificatesv1.KubeAPIServerClientSignerName)
+	}
+	if usages := sets.New(csr.Spec.Usages...); !usages.Equal(RequiredUsages) {
+		return nil, fmt.Errorf("usages %v do not match %v", sets.List(usages), sets.List(RequiredUsages))
+	}
+
+	req, err := ParseCertificateRequest(csr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate request: %w", err)
+	}
+	if !slices.Equal([]string{organization}, req.Subject.Organization) {
+		return nil, fmt.Errorf("organization %v is not [%s]", req.Subject.Organization, organization)
+	}
+	if len(req.DNSNames) > 0 || len(req.EmailAddresses) > 0 || len(req.IPAddresses) > 0 || len(req.URIs) > 0 {
+		return nil, fmt.Errorf("subject alternative names are not allowed")
+	}
+
+	poolName, ok := strings.CutPrefix(req.Subject.CommonName, userNamePrefix)
+	if !ok || poolName == "" {
+		return nil, fmt.Errorf("common name %s is not of the form %s<pool-name>", req.Subject.CommonName, userNamePrefix)
+	}
+
+	scopedPoolNames := bootstraptoken.PoolNamesOfGroups(typ, csr.Spec.Groups)
+	if len(scopedPoolNames) > 0 && !slices.Contains(scopedPoolNames, poolName) {
+		return nil, fmt.Errorf("pool name %s is not among the pools %v the token is scoped to", poolName, scopedPoolNames)
+	}
+
+	return &Request{
+		Type:       typ,
+		TokenID:    strings.TrimPrefix(csr.Spec.Username, bootstrapapi.BootstrapUserPrefix),
+		PoolName:   poolName,
+		PoolScoped: len(scopedPoolNames) > 0,
+	}, nil
+}
+
+// ListPending lists all pending certificate signing requests made by users authenticated with a bootstrap token
+// of an ironcore token type (see IsIroncore).
+func ListPending(ctx context.Context, c client.Reader) ([]certificatesv1.CertificateSigningRequest, error) {
+	csrList := &certificatesv1.CertificateSigningRequestList{}
+	if err := c.List(ctx, csrList); err != nil {
+		return nil, fmt.Errorf("error listing certificate signing requests: %w", err)
+	}
+
+	var res []certificatesv1.CertificateSigningRequest
+	for _, csr := range csrList.Items {
+		if IsIroncore(&csr) && IsPending(&csr) {
+			res = append(res, csr)
+		}
+	}
+	return res, nil
+}
+
+// Approve approves the given certificate signing request with the given message.
+func Approve(ctx context.Context, c client.Client, csr *certificatesv1.CertificateSigningRequest, message string, opts ...client.SubResourceUpdateOption) error {
+	SetApproved(csr, message)
+	return updateApproval(ctx, c, csr, opts)
+}
+
+// Deny denies the given certificate signing request with the given message.
+func Deny(ctx context.Context, c client.Client, csr *certificatesv1.CertificateSigningRequest, message string, opts ...client.SubResourceUpdateOption) error {
+	SetDenied(csr, message)
+	return updateApproval(ctx, c, csr, opts)
+}
+
+// SetApproved adds an approval condition with the given message to the given certificate signing request
+// without updating it, e.g. for printing it in client dry run mode.
+func SetApproved(csr *certificatesv1.CertificateSigningRequest, message string) {
+	addCondition(csr, certificatesv1.CertificateApproved, ApprovedReason, message)
+}
+
+// SetDenied adds a denial condition with the given message to the given certificate signing request
+// without updating it, e.g. for printing it in client dry run mode.
+func SetDenied(csr *certificatesv1.CertificateSigningRequest, message string) {
+	addCondition(csr, certificatesv1.CertificateDenied, DeniedReason, message)
+}
+
+func addCondition(
+	csr *certificatesv1.CertificateSigningRequest,
+	typ certificatesv1.RequestConditionType,
+	reason, message string,
+) {
+	csr.Status.Conditions = append(csr.Status.Conditions, certificatesv1.CertificateSigningRequestCondition{
+		Type:           typ,
+		Status:         corev1.ConditionTrue,
+		Reason:         reason,
+		Message:        message,
+		LastUpdateTime: metav1.Now(),
+	})
+}
+
+func updateApproval(
+	ctx context.Context,
+	c client.Client,
+	csr *certificatesv1.CertificateSigningRequest,
+	opts []client.SubResourceUpdateOption,
+) error {
+	if err := c.SubResource("approval").Update(ctx, csr, opts...); err != nil {
+		return fmt.Errorf("error updating approval of certificate signing request %s: %w", csr.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrapcsr
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/ironcore-dev/kubectl-ironcore/bootstraptoken"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Policy restricts which valid certificate signing requests are approved.
+// The zero value allows all valid certificate signing requests.
+type Policy struct {
+	// Types are the token types whose certificate signing requests may be approved. If empty, all types are allowed.
+	Types []bootstraptoken.Type `json:"types,omitempty"`
+	// PoolNamePattern is a regular expression pool names have to match.
+	PoolNamePattern string `json:"poolNamePattern,omitempty"`
+	// MaxDuration is the maximum certificate duration that may be requested.
+	// If set, certificate signing requests have to request a duration.
+	MaxDuration *metav1.Duration `json:"maxDuration,omitempty"`
+	// RequirePoolScope only allows certificate signing requests made with tokens scoped to their pool.
+	RequirePoolScope bool `json:"requirePoolScope,omitempty"`
+
+	poolNameRegexp *regexp.Regexp
+}
+
+// LoadPolicyFile reads and validates the Policy from the given file.
+func LoadPolicyFile(filename string) (*Policy, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error reading policy file: %w", err)
+	}
+
+	policy := &Policy{}
+	if err := yaml.UnmarshalStrict(data, policy); err != nil {
+		return nil, fmt.Errorf("error decoding policy file %s: %w", filename, err)
+	}
+	if err := policy.Complete(); err != nil {
+		return nil, fmt.Errorf("invalid policy file %s: %w", filename, err)
+	}
+	return policy, nil
+}
+
+// Complete validates the policy and compiles its pool name pattern.
+func (p *Policy) Complete() error {
+	for _, typ := range p.Types {
+		if !bootstraptoken.AvailableTypes.Has(typ) {
+			return fmt.Errorf("unknown token type %s", typ)
+		}
+	}
+	if p.PoolNamePattern != "" {
+		poolNameRegexp, err := regexp.Compile(p.PoolNamePattern)
+		if err != nil {
+			return fmt.Errorf("invalid pool name pattern: %w", err)
+		}
+		p.poolNameRegexp = poolNameRegexp
+	}
+	if p.MaxDuration != nil && p.MaxDuration.Duration <= 0 {
+		return fmt.Errorf("max duration has to be positive")
+	}
+	return nil
+}
+
+// Check checks whether the given validated certificate signing request is allowed by the policy.
+// The returned error describes why it is not.
+func (p *Policy) Check(csr *certificatesv1.CertificateSigningRequest, req *Request) error {
+	if len(p.Types) > 0 && !slices.Contains(p.Types, req.Type) {
+		return fmt.Errorf("token type %s is not allowed by policy", req.Type)
+	}
+	if p.poolNameRegexp != nil && !p.poolNameRegexp.MatchString(req.PoolName) {
+		return fmt.Errorf("pool name %s does not match policy pattern %s", req.PoolName, p.PoolNamePattern)
+	}
+	if p.RequirePoolScope && !req.PoolScoped {
+		return fmt.Errorf("token is not scoped to a pool but policy requires it")
+	}
+	if p.MaxDuration != nil {
+		if csr.Spec.ExpirationSeconds == nil {
+			return fmt.Errorf("no certificate duration requested but policy limits it to %s", p.MaxDuration.Duration)
+		}
+		if duration := time.Duration(*csr.Spec.ExpirationSeconds) * time.Second; duration > p.MaxDuration.Duration {
+			return fmt.Errorf("requested certificate duration %s exceeds policy maximum %s", duration, p.MaxDuration.Duration)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrapcsr
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	bootstrapapi "k8s.io/cluster-bootstrap/token/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// WatchRetryPeriod is the time Watch waits before listing and watching again after an error.
+const WatchRetryPeriod = 5 * time.Second
+
+// Handler handles a certificate signing request that was added (or already existed), modified or deleted
+// and reports whether watching is done.
+type Handler func(eventType watch.EventType, csr *certificatesv1.CertificateSigningRequest) (done bool)
+
+// Watch calls handle for all existing and then for all added, modified or deleted certificate signing requests
+// until handle reports it is done or the context is done, in which case the context error is returned.
+// Existing certificate signing requests are handled as watch.Added.
+// Errors listing or watching are logged to the logger of the context and retried.
+func Watch(ctx context.Context, c client.WithWatch, handle Handler) error {
+	log := logr.FromContextOrDiscard(ctx)
+	for {
+		done, err := listAndWatch(ctx, c, handle)
+		if done {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == nil {
+			log.V(1).Info("Watch closed, restarting")
+			continue
+		}
+		log.Error(err, "Error watching certificate signing requests, retrying", "RetryPeriod", WatchRetryPeriod)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(WatchRetryPeriod):
+		}
+	}
+}
+
+// WaitForFirst waits for the first certificate signing request made with the bootstrap token
+// of the given id to be submitted and returns it.
+func WaitForFirst(ctx context.Context, c client.WithWatch, tokenID string) (*certificatesv1.CertificateSigningRequest, error) {
+	username := bootstrapapi.BootstrapUserPrefix + tokenID
+
+	var first *certificatesv1.CertificateSigningRequest
+	if err := Watch(ctx, c, func(_ watch.EventType, csr *certificatesv1.CertificateSigningRequest) bool {
+		if csr.Spec.Username != username {
+			return false
+		}
+		first = csr.DeepCopy()
+		return true
+	}); err != nil {
+		return nil, err
+	}
+	return first, nil
+}
+
+// listAndWatch handles all existing certificate signing requests and then all added or modified ones
+// until handle is done or the watch ends. A watch closed by the server is not an error.
+func listAndWatch(ctx context.Context, c client.WithWatch, handle Handler) (bool, error) {
+	csrList := &certificatesv1.CertificateSigningRequestList{}
+	if err := c.List(ctx, csrList); err != nil {
+		return false, fmt.Errorf("error listing certificate signing requests: %w", err)
+	}
+	for i := range csrList.Items {
+		if handle(watch.Added, &csrList.Items[i]) {
+			return true, nil
+		}
+	}
+
+	w, err := c.Watch(ctx, &certificatesv1.CertificateSigningRequestList{}, &client.ListOptions{
+		Raw: &metav1.ListOptions{ResourceVersion: csrList.ResourceVersion},
+	})
+	if err != nil {
+		return false, fmt.Errorf("error watching certificate signing requests: %w", err)
+	}
+	defer w.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return false, nil
+			}
+			switch event.Type {
+			case watch.Added, watch.Modified, watch.Deleted:
+				if csr, ok := event.Object.(*certificatesv1.CertificateSigningRequest); ok && handle(event.Type, csr) {
+					return true, nil
+				}
+			case watch.Error:
+				return false, fmt.Errorf("watch error: %w", apierrors.FromObject(event.Object))
+			}
+		}
+	}
+}
@@ -23,6 +23,21 @@ type GenerateOptions struct {
 	// BootstrapContext is context name of the resulting bootstrap kubeconfig.
 	// If empty, DefaultBootstrapContext will be used.
 	BootstrapContext string
+
+	// Server overrides the server of the cluster if non-empty.
+	Server string
+
+	// CertificateAuthority overrides the certificate authority file of the cluster if non-empty.
+	CertificateAuthority string
+
+	// CertificateAuthorityData overrides the certificate authority data of the cluster if non-empty.
+	CertificateAuthorityData []byte
+
+	// TLSServerName overrides the TLS server name of the cluster if non-empty.
+	TLSServerName string
+
+	// ProxyURL overrides the proxy url of the cluster if non-empty.
+	ProxyURL string
 }
 
 func (o *GenerateOptions) ApplyOptions(opts []func(*GenerateOptions)) {
@@ -38,6 +53,41 @@ func WithContext(context string) func(*GenerateOptions) {
 	}
 }
 
+// WithServer overrides the server of the cluster.
+func WithServer(server string) func(*GenerateOptions) {
+	return func(options *GenerateOptions) {
+		options.Server = server
+	}
+}
+
+// WithCertificateAuthority overrides the certificate authority file of the cluster.
+func WithCertificateAuthority(certificateAuthority string) func(*GenerateOptions) {
+	return func(options *GenerateOptions) {
+		options.CertificateAuthority = certificateAuthority
+	}
+}
+
+// WithCertificateAuthorityData overrides the certificate authority data of the cluster.
+func WithCertificateAuthorityData(certificateAuthorityData []byte) func(*GenerateOptions) {
+	return func(options *GenerateOptions) {
+		options.CertificateAuthorityData = certificateAuthorityData
+	}
+}
+
+// WithTLSServerName overrides the TLS server name of the cluster.
+func WithTLSServerName(tlsServerName string) func(*GenerateOptions) {
+	return func(options *GenerateOptions) {
+		options.TLSServerName = tlsServerName
+	}
+}
+
+// WithProxyURL overrides the proxy url of the cluster.
+func WithProxyURL(proxyURL string) func(*GenerateOptions) {
+	return func(options *GenerateOptions) {
+		options.ProxyURL = proxyURL
+	}
+}
+
 func setGenerateOptionsDefaults(o *GenerateOptions) {
 	if o.BootstrapContext == "" {
 		o.BootstrapContext = DefaultBootstrapContext
@@ -62,16 +112,41 @@ func Generate(
 		return nil, fmt.Errorf("could not determine context name to use")
 	}
 
-	context, ok := startingCfg.Contexts[startingCfg.CurrentContext]
+	if o.CertificateAuthority != "" && len(o.CertificateAuthorityData) > 0 {
+		return nil, fmt.Errorf("cannot override both certificate authority file and data")
+	}
+
+	context, ok := startingCfg.Contexts[contextName]
 	if !ok {
-		return nil, fmt.Errorf("context %q not found", startingCfg.CurrentContext)
+		return nil, fmt.Errorf("context %q not found", contextName)
 	}
 
-	cluster, ok := startingCfg.Clusters[context.Cluster]
+	startingCluster, ok := startingCfg.Clusters[context.Cluster]
 	if !ok {
 		return nil, fmt.Errorf("cluster %q not found", context.Cluster)
 	}
 
+	cluster := startingCluster.DeepCopy()
+	if o.Server != "" {
+		cluster.Server = o.Server
+	}
+	if o.CertificateAuthority != "" {
+		cluster.CertificateAuthority = o.CertificateAuthority
+		cluster.CertificateAuthorityData = nil
+		cluster.InsecureSkipTLSVerify = false
+	}
+	if len(o.CertificateAuthorityData) > 0 {
+		cluster.CertificateAuthority = ""
+		cluster.CertificateAuthorityData = o.CertificateAuthorityData
+		cluster.InsecureSkipTLSVerify = false
+	}
+	if o.TLSServerName != "" {
+		cluster.TLSServerName = o.TLSServerName
+	}
+	if o.ProxyURL != "" {
+		cluster.ProxyURL = o.ProxyURL
+	}
+
 	return &clientcmdapi.Config{
 		Preferences: startingCfg.Preferences,
 		Clusters: map[string]*clientcmdapi.Cluster{
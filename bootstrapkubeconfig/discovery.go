@@ -0,0 +1,179 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrapkubeconfig
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ironcore-dev/kubectl-ironcore/utils/bootstraptoken"
+	"github.com/ironcore-dev/kubectl-ironcore/utils/pubkeypin"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	certutil "k8s.io/client-go/util/cert"
+	bootstrapapi "k8s.io/cluster-bootstrap/token/api"
+	jws "k8s.io/cluster-bootstrap/token/jws"
+)
+
+const (
+	// DiscoveryContext is the context name of kubeconfigs returned by Discover.
+	DiscoveryContext = "cluster-info"
+)
+
+// DiscoverOptions are options for discovering a cluster via its cluster-info ConfigMap.
+type DiscoverOptions struct {
+	// CACertHashes are public key pins of the form 'sha256:<hex>' the cluster CA has to match.
+	CACertHashes []string
+
+	// UnsafeSkipCAVerification allows discovering without CACertHashes, trusting whatever CA
+	// the (correctly signed) cluster-info ConfigMap contains.
+	UnsafeSkipCAVerification bool
+}
+
+func (o *DiscoverOptions) ApplyOptions(opts []func(*DiscoverOptions)) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithCACertHashes sets the public key pins the cluster CA has to match.
+func WithCACertHashes(caCertHashes ...string) func(*DiscoverOptions) {
+	return func(options *DiscoverOptions) {
+		options.CACertHashes = append(options.CACertHashes, caCertHashes...)
+	}
+}
+
+// WithUnsafeSkipCAVerification allows discovering without CA cert hashes.
+func WithUnsafeSkipCAVerification(unsafeSkipCAVerification bool) func(*DiscoverOptions) {
+	return func(options *DiscoverOptions) {
+		options.UnsafeSkipCAVerification = unsafeSkipCAVerification
+	}
+}
+
+// Discover fetches the cluster-info ConfigMap from the given server, verifies its signature for the given
+// token and (if CA cert hashes are given) its CA. The resulting config has DiscoveryContext as current context and
+// can be used as starting config for Generate.
+func Discover(
+	ctx context.Context,
+	server string,
+	token *bootstraptoken.BootstrapToken,
+	opts ...func(*DiscoverOptions),
+) (*clientcmdapi.Config, error) {
+	o := &DiscoverOptions{}
+	o.ApplyOptions(opts)
+
+	pins := pubkeypin.NewSet()
+	if err := pins.Allow(o.CACertHashes...); err != nil {
+		return nil, err
+	}
+	if pins.Empty() && !o.UnsafeSkipCAVerification {
+		return nil, fmt.Errorf("must specify CA cert hashes or explicitly skip CA verification")
+	}
+
+	insecureCfg := &rest.Config{
+		Host:            server,
+		TLSClientConfig: rest.TLSClientConfig{Insecure: true},
+	}
+	clusterInfo, err := getClusterInfo(ctx, insecureCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	kubeconfigData, err := VerifyClusterInfo(clusterInfo, token)
+	if err != nil {
+		return nil, err
+	}
+
+	cluster, err := clusterFromKubeconfig(kubeconfigData)
+	if err != nil {
+		return nil, err
+	}
+
+	if !pins.Empty() {
+		caCerts, err := certutil.ParseCertsPEM(cluster.CertificateAuthorityData)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing cluster-info certificate authority data: %w", err)
+		}
+		if err := pins.CheckAny(caCerts); err != nil {
+			return nil, fmt.Errorf("cluster-info certificate authority is not trusted: %w", err)
+		}
+
+		// Fetch cluster-info again, this time verifying the server with the pinned CA.
+		secureCfg := &rest.Config{
+			Host:            server,
+			TLSClientConfig: rest.TLSClientConfig{CAData: cluster.CertificateAuthorityData},
+		}
+		secureClusterInfo, err := getClusterInfo(ctx, secureCfg)
+		if err != nil {
+			return nil, err
+		}
+		if secureClusterInfo.Data[bootstrapapi.KubeConfigKey] != kubeconfigData {
+			return nil, fmt.Errorf("cluster-info ConfigMap changed between insecure and secure fetch")
+		}
+	}
+
+	return &clientcmdapi.Config{
+		Clusters: map[string]*clientcmdapi.Cluster{
+			DiscoveryContext: cluster,
+		},
+		AuthInfos: map[string]*clientcmdapi.AuthInfo{},
+		Contexts: map[string]*clientcmdapi.Context{
+			DiscoveryContext: {
+				Cluster: DiscoveryContext,
+			},
+		},
+		CurrentContext: DiscoveryContext,
+	}, nil
+}
+
+// VerifyClusterInfo verifies the given cluster-info ConfigMap is signed for the given token and
+// returns its kubeconfig data.
+func VerifyClusterInfo(clusterInfo *corev1.ConfigMap, token *bootstraptoken.BootstrapToken) (string, error) {
+	kubeconfigData, ok := clusterInfo.Data[bootstrapapi.KubeConfigKey]
+	if !ok || kubeconfigData == "" {
+		return "", fmt.Errorf("cluster-info ConfigMap has no %s key", bootstrapapi.KubeConfigKey)
+	}
+
+	signature, ok := clusterInfo.Data[bootstrapapi.JWSSignatureKeyPrefix+token.ID]
+	if !ok {
+		return "", fmt.Errorf("cluster-info ConfigMap has no signature for token id %s", token.ID)
+	}
+	if !jws.DetachedTokenIsValid(signature, kubeconfigData, token.ID, token.Secret) {
+		return "", fmt.Errorf("cluster-info ConfigMap signature for token id %s is invalid", token.ID)
+	}
+	return kubeconfigData, nil
+}
+
+func getClusterInfo(ctx context.Context, cfg *rest.Config) (*corev1.ConfigMap, error) {
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterInfo, err := clientset.CoreV1().ConfigMaps(metav1.NamespacePublic).Get(ctx, bootstrapapi.ConfigMapClusterInfo, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error getting cluster-info ConfigMap: %w", err)
+	}
+	return clusterInfo, nil
+}
+
+func clusterFromKubeconfig(data string) (*clientcmdapi.Cluster, error) {
+	cfg, err := clientcmd.Load([]byte(data))
+	if err != nil {
+		return nil, fmt.Errorf("error loading cluster-info kubeconfig: %w", err)
+	}
+	if len(cfg.Clusters) != 1 {
+		return nil, fmt.Errorf("expected exactly one cluster in cluster-info kubeconfig but got %d", len(cfg.Clusters))
+	}
+
+	var cluster *clientcmdapi.Cluster
+	for _, c := range cfg.Clusters {
+		cluster = c
+	}
+	return cluster, nil
+}
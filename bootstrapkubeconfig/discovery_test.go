@@ -0,0 +1,281 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrapkubeconfig
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ironcore-dev/kubectl-ironcore/utils/bootstraptoken"
+	"github.com/ironcore-dev/kubectl-ironcore/utils/pubkeypin"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	bootstrapapi "k8s.io/cluster-bootstrap/token/api"
+	jws "k8s.io/cluster-bootstrap/token/jws"
+)
+
+var (
+	testToken  = &bootstraptoken.BootstrapToken{ID: "abcdef", Secret: "0123456789abcdef"}
+	otherToken = &bootstraptoken.BootstrapToken{ID: "ghijkl", Secret: "fedcba9876543210"}
+
+	unknownCACertHash = pubkeypin.FormatSHA256 + ":" + strings.Repeat("00", 32)
+)
+
+// clusterInfoServer serves the cluster-info ConfigMap. The n-th request is answered with the n-th of clusterInfos,
+// later requests with the last one.
+type clusterInfoServer struct {
+	*httptest.Server
+
+	clusterInfos []*corev1.ConfigMap
+	requests     atomic.Int32
+}
+
+func newClusterInfoServer(t *testing.T) *clusterInfoServer {
+	s := &clusterInfoServer{}
+	s.Server = httptest.NewTLSServer(s)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *clusterInfoServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != "/api/v1/namespaces/"+metav1.NamespacePublic+"/configmaps/"+bootstrapapi.ConfigMapClusterInfo {
+		http.NotFound(w, req)
+		return
+	}
+
+	n := int(s.requests.Add(1))
+	clusterInfo := s.clusterInfos[min(n, len(s.clusterInfos))-1]
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(clusterInfo)
+}
+
+// caCertHash returns the public key pin of the server certificate.
+func (s *clusterInfoServer) caCertHash() string {
+	return pubkeypin.Hash(s.Certificate())
+}
+
+// kubeconfig returns cluster-info kubeconfig data pointing to the given server and trusting the server certificate.
+func (s *clusterInfoServer) kubeconfig(t *testing.T, server string) string {
+	t.Helper()
+
+	data, err := clientcmd.Write(clientcmdapi.Config{
+		Clusters: map[string]*clientcmdapi.Cluster{
+			"": {
+				Server:                   server,
+				CertificateAuthorityData: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.Certificate().Raw}),
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("error writing kubeconfig: %v", err)
+	}
+	return string(data)
+}
+
+// newClusterInfo creates a cluster-info ConfigMap containing the given kubeconfig data signed for the given tokens.
+func newClusterInfo(t *testing.T, kubeconfigData string, tokens ...*bootstraptoken.BootstrapToken) *corev1.ConfigMap {
+	t.Helper()
+
+	clusterInfo := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: metav1.NamespacePublic,
+			Name:      bootstrapapi.ConfigMapClusterInfo,
+		},
+		Data: map[string]string{
+			bootstrapapi.KubeConfigKey: kubeconfigData,
+		},
+	}
+	for _, token := range tokens {
+		signature, err := jws.ComputeDetachedSignature(kubeconfigData, token.ID, token.Secret)
+		if err != nil {
+			t.Fatalf("error signing cluster-info: %v", err)
+		}
+		clusterInfo.Data[bootstrapapi.JWSSignatureKeyPrefix+token.ID] = signature
+	}
+	return clusterInfo
+}
+
+func TestDiscover(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		// clusterInfos returns the ConfigMaps served by the server.
+		clusterInfos func(t *testing.T, s *clusterInfoServer) []*corev1.ConfigMap
+		// opts returns the options to discover with.
+		opts             func(s *clusterInfoServer) []func(*DiscoverOptions)
+		expectError      string
+		expectedRequests int32
+	}{
+		{
+			name: "valid signature and CA",
+			clusterInfos: func(t *testing.T, s *clusterInfoServer) []*corev1.ConfigMap {
+				return []*corev1.ConfigMap{newClusterInfo(t, s.kubeconfig(t, s.URL), otherToken, testToken)}
+			},
+			opts: func(s *clusterInfoServer) []func(*DiscoverOptions) {
+				return []func(*DiscoverOptions){WithCACertHashes(unknownCACertHash, s.caCertHash())}
+			},
+			expectedRequests: 2,
+		},
+		{
+			name: "missing signature for token id",
+			clusterInfos: func(t *testing.T, s *clusterInfoServer) []*corev1.ConfigMap {
+				return []*corev1.ConfigMap{newClusterInfo(t, s.kubeconfig(t, s.URL), otherToken)}
+			},
+			opts: func(s *clusterInfoServer) []func(*DiscoverOptions) {
+				return []func(*DiscoverOptions){WithCACertHashes(s.caCertHash())}
+			},
+			expectError:      "no signature for token id " + testToken.ID,
+			expectedRequests: 1,
+		},
+		{
+			name: "bad signature",
+			clusterInfos: func(t *testing.T, s *clusterInfoServer) []*corev1.ConfigMap {
+				wrongSecret := &bootstraptoken.BootstrapToken{ID: testToken.ID, Secret: otherToken.Secret}
+				return []*corev1.ConfigMap{newClusterInfo(t, s.kubeconfig(t, s.URL), wrongSecret)}
+			},
+			opts: func(s *clusterInfoServer) []func(*DiscoverOptions) {
+				return []func(*DiscoverOptions){WithCACertHashes(s.caCertHash())}
+			},
+			expectError:      "signature for token id " + testToken.ID + " is invalid",
+			expectedRequests: 1,
+		},
+		{
+			name: "CA not matching hash",
+			clusterInfos: func(t *testing.T, s *clusterInfoServer) []*corev1.ConfigMap {
+				return []*corev1.ConfigMap{newClusterInfo(t, s.kubeconfig(t, s.URL), testToken)}
+			},
+			opts: func(s *clusterInfoServer) []func(*DiscoverOptions) {
+				return []func(*DiscoverOptions){WithCACertHashes(unknownCACertHash)}
+			},
+			expectError:      "certificate authority is not trusted",
+			expectedRequests: 1,
+		},
+		{
+			name: "unsafe skip CA verification",
+			clusterInfos: func(t *testing.T, s *clusterInfoServer) []*corev1.ConfigMap {
+				return []*corev1.ConfigMap{newClusterInfo(t, s.kubeconfig(t, s.URL), testToken)}
+			},
+			opts: func(s *clusterInfoServer) []func(*DiscoverOptions) {
+				return []func(*DiscoverOptions){WithUnsafeSkipCAVerification(true)}
+			},
+			expectedRequests: 1,
+		},
+		{
+			name: "neither CA cert hashes nor unsafe skip CA verification",
+			clusterInfos: func(t *testing.T, s *clusterInfoServer) []*corev1.ConfigMap {
+				return []*corev1.ConfigMap{newClusterInfo(t, s.kubeconfig(t, s.URL), testToken)}
+			},
+			opts: func(s *clusterInfoServer) []func(*DiscoverOptions) {
+				return nil
+			},
+			expectError: "must specify CA cert hashes",
+		},
+		{
+			name: "cluster-info changed between insecure and pinned fetch",
+			clusterInfos: func(t *testing.T, s *clusterInfoServer) []*corev1.ConfigMap {
+				return []*corev1.ConfigMap{
+					newClusterInfo(t, s.kubeconfig(t, s.URL), testToken),
+					newClusterInfo(t, s.kubeconfig(t, "https://attacker.example.com"), testToken),
+				}
+			},
+			opts: func(s *clusterInfoServer) []func(*DiscoverOptions) {
+				return []func(*DiscoverOptions){WithCACertHashes(s.caCertHash())}
+			},
+			expectError:      "changed between insecure and secure fetch",
+			expectedRequests: 2,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newClusterInfoServer(t)
+			s.clusterInfos = tc.clusterInfos(t, s)
+
+			cfg, err := Discover(context.Background(), s.URL, testToken, tc.opts(s)...)
+			if n := s.requests.Load(); n != tc.expectedRequests {
+				t.Errorf("expected %d requests, got %d", tc.expectedRequests, n)
+			}
+			if tc.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectError) {
+					t.Fatalf("expected an error containing %q, got %v", tc.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if cfg.CurrentContext != DiscoveryContext {
+				t.Errorf("expected current context %q, got %q", DiscoveryContext, cfg.CurrentContext)
+			}
+			cluster := cfg.Clusters[DiscoveryContext]
+			if cluster == nil {
+				t.Fatalf("expected cluster %q", DiscoveryContext)
+			}
+			if cluster.Server != s.URL {
+				t.Errorf("expected server %q, got %q", s.URL, cluster.Server)
+			}
+			if !bytes.Equal(cluster.CertificateAuthorityData, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.Certificate().Raw})) {
+				t.Error("expected certificate authority data of the server certificate")
+			}
+		})
+	}
+}
+
+func TestVerifyClusterInfo(t *testing.T) {
+	const kubeconfigData = "kubeconfig"
+
+	for _, tc := range []struct {
+		name        string
+		clusterInfo *corev1.ConfigMap
+		expectError bool
+	}{
+		{
+			name:        "valid signature",
+			clusterInfo: newClusterInfo(t, kubeconfigData, testToken),
+		},
+		{
+			name:        "missing kubeconfig",
+			clusterInfo: newClusterInfo(t, "", testToken),
+			expectError: true,
+		},
+		{
+			name:        "missing signature for token id",
+			clusterInfo: newClusterInfo(t, kubeconfigData, otherToken),
+			expectError: true,
+		},
+		{
+			name: "signature of different content",
+			clusterInfo: func() *corev1.ConfigMap {
+				clusterInfo := newClusterInfo(t, kubeconfigData, testToken)
+				clusterInfo.Data[bootstrapapi.KubeConfigKey] = "tampered"
+				return clusterInfo
+			}(),
+			expectError: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := VerifyClusterInfo(tc.clusterInfo, testToken)
+			if tc.expectError {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if data != kubeconfigData {
+				t.Errorf("expected kubeconfig data %q, got %q", kubeconfigData, data)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrapkubeconfig
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+const (
+	DefaultSecretName = "bootstrap-kubeconfig"
+	DefaultSecretKey  = "kubeconfig"
+)
+
+// ToSecret wraps the given bootstrap kubeconfig into a secret with the given name and namespace,
+// storing it under the given key.
+func ToSecret(apiCfg *clientcmdapi.Config, name, namespace, key string) (*corev1.Secret, error) {
+	apiCfgData, err := clientcmd.Write(*apiCfg)
+	if err != nil {
+		return nil, fmt.Errorf("error serializing bootstrap kubeconfig: %w", err)
+	}
+
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: corev1.SchemeGroupVersion.String(),
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			key: apiCfgData,
+		},
+	}, nil
+}
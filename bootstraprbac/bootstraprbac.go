@@ -0,0 +1,177 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstraprbac
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ironcore-dev/kubectl-ironcore/bootstraptoken"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	bootstrapapi "k8s.io/cluster-bootstrap/token/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ClusterInfoRoleName is the name of the Role granting access to the cluster-info ConfigMap.
+	ClusterInfoRoleName = "ironcore:bootstrap-signer-clusterinfo"
+
+	certificateSigningRequestsResource = "certificatesigningrequests"
+)
+
+// Well-known users and groups of the Kubernetes API server authentication.
+const (
+	// AnonymousUser is the name of unauthenticated users.
+	AnonymousUser = "system:anonymous"
+	// AllAuthenticatedGroup is the group of all authenticated users.
+	AllAuthenticatedGroup = "system:authenticated"
+	// AllUnauthenticatedGroup is the group of all unauthenticated users.
+	AllUnauthenticatedGroup = "system:unauthenticated"
+)
+
+// BootstrapperName returns the name of the ClusterRole and ClusterRoleBinding for the given type.
+func BootstrapperName(typ bootstraptoken.Type) string {
+	return fmt.Sprintf("ironcore:%s-bootstrapper", strings.ToLower(string(typ)))
+}
+
+// ForTypeOptions are options for generating the RBAC of a token type.
+type ForTypeOptions struct {
+	// PoolScoped omits the permission to have certificate signing requests auto-approved. RBAC cannot
+	// restrict the pool name requested, so certificate signing requests have to be approved by an approver
+	// checking the pool scope of tokens (see bootstraptoken.PoolGroup) instead.
+	PoolScoped bool
+}
+
+func (o *ForTypeOptions) ApplyOptions(opts []func(*ForTypeOptions)) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithPoolScoped omits the permission to have certificate signing requests auto-approved.
+func WithPoolScoped(poolScoped bool) func(*ForTypeOptions) {
+	return func(options *ForTypeOptions) {
+		options.PoolScoped = poolScoped
+	}
+}
+
+// ForType returns the ClusterRole and ClusterRoleBinding allowing tokens of the given type
+// to create certificate signing requests for their component and, unless pool-scoped,
+// to have them auto-approved.
+func ForType(typ bootstraptoken.Type, opts ...func(*ForTypeOptions)) ([]client.Object, error) {
+	o := &ForTypeOptions{}
+	o.ApplyOptions(opts)
+
+	groups, err := bootstraptoken.Groups(typ)
+	if err != nil {
+		return nil, err
+	}
+
+	csrSubresource, err := bootstraptoken.CSRSubresource(typ)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := []rbacv1.PolicyRule{
+		{
+			APIGroups: []string{certificatesv1.GroupName},
+			Resources: []string{certificateSigningRequestsResource},
+			Verbs:     []string{"create", "get", "list", "watch"},
+		},
+	}
+	if csrSubresource != "" && !o.PoolScoped {
+		rules = append(rules, rbacv1.PolicyRule{
+			APIGroups: []string{certificatesv1.GroupName},
+			Resources: []string{fmt.Sprintf("%s/%s", certificateSigningRequestsResource, csrSubresource)},
+			Verbs:     []string{"create"},
+		})
+	}
+
+	name := BootstrapperName(typ)
+	subjects := make([]rbacv1.Subject, 0, len(groups))
+	for _, group := range groups {
+		subjects = append(subjects, rbacv1.Subject{
+			APIGroup: rbacv1.GroupName,
+			Kind:     rbacv1.GroupKind,
+			Name:     group,
+		})
+	}
+
+	return []client.Object{
+		&rbacv1.ClusterRole{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: rbacv1.SchemeGroupVersion.String(),
+				Kind:       "ClusterRole",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Rules: rules,
+		},
+		&rbacv1.ClusterRoleBinding{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: rbacv1.SchemeGroupVersion.String(),
+				Kind:       "ClusterRoleBinding",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			RoleRef: rbacv1.RoleRef{
+				APIGroup: rbacv1.GroupName,
+				Kind:     "ClusterRole",
+				Name:     name,
+			},
+			Subjects: subjects,
+		},
+	}, nil
+}
+
+// ClusterInfo returns the Role and RoleBinding allowing anonymous users to read the
+// cluster-info ConfigMap, which is required for token discovery.
+func ClusterInfo() []client.Object {
+	return []client.Object{
+		&rbacv1.Role{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: rbacv1.SchemeGroupVersion.String(),
+				Kind:       "Role",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: metav1.NamespacePublic,
+				Name:      ClusterInfoRoleName,
+			},
+			Rules: []rbacv1.PolicyRule{
+				{
+					APIGroups:     []string{""},
+					Resources:     []string{"configmaps"},
+					ResourceNames: []string{bootstrapapi.ConfigMapClusterInfo},
+					Verbs:         []string{"get"},
+				},
+			},
+		},
+		&rbacv1.RoleBinding{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: rbacv1.SchemeGroupVersion.String(),
+				Kind:       "RoleBinding",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: metav1.NamespacePublic,
+				Name:      ClusterInfoRoleName,
+			},
+			RoleRef: rbacv1.RoleRef{
+				APIGroup: rbacv1.GroupName,
+				Kind:     "Role",
+				Name:     ClusterInfoRoleName,
+			},
+			Subjects: []rbacv1.Subject{
+				{
+					APIGroup: rbacv1.GroupName,
+					Kind:     rbacv1.UserKind,
+					Name:     AnonymousUser,
+				},
+			},
+		},
+	}
+}
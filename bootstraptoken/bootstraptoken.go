@@ -5,9 +5,14 @@ package bootstraptoken
 
 import (
 	"fmt"
+	"strings"
 
+	computev1alpha1 "github.com/ironcore-dev/ironcore/api/compute/v1alpha1"
+	networkingv1alpha1 "github.com/ironcore-dev/ironcore/api/networking/v1alpha1"
+	storagev1alpha1 "github.com/ironcore-dev/ironcore/api/storage/v1alpha1"
 	"github.com/ironcore-dev/kubectl-ironcore/utils/bootstraptoken"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/cluster-bootstrap/token/util"
 )
 
 type Type string
@@ -30,6 +35,15 @@ const (
 	MetalnetletBootstrappersGroup   = "system:bootstrappers:apinet-ironcore-dev:metalnetlets"
 )
 
+// Certificate conventions of the apinet components.
+const (
+	APINetletsGroup         = "apinet.ironcore.dev:system:apinetlets"
+	APINetletUserNamePrefix = "apinet.ironcore.dev:system:apinetlet:"
+
+	MetalnetletsGroup         = "apinet.ironcore.dev:system:metalnetlets"
+	MetalnetletUserNamePrefix = "apinet.ironcore.dev:system:metalnetlet:"
+)
+
 var AvailableTypes = sets.New[Type](
 	MachinePoolType,
 	VolumePoolType,
@@ -39,10 +53,23 @@ var AvailableTypes = sets.New[Type](
 	MetalnetletType,
 )
 
+// builtinTypes are the types that can only be replaced if overriding built-in types is requested.
+var builtinTypes = AvailableTypes.Clone()
+
 type fields struct {
 	Description string
 	Usages      []string
 	Groups      []string
+	// JoinCommand is the command line of the component registering with tokens of the type.
+	JoinCommand string
+	// CSRSubresource is the certificatesigningrequests subresource permission on which lets
+	// certificate signing requests of the component be auto-approved.
+	CSRSubresource string
+	// CertificateOrganization is the organization client certificates of the component have to request.
+	CertificateOrganization string
+	// CertificateUserNamePrefix is the prefix of the common name client certificates of the component have to request.
+	// The remainder of the common name is the name of the registering pool.
+	CertificateUserNamePrefix string
 }
 
 var fieldsByType = map[Type]fields{
@@ -55,6 +82,11 @@ var fieldsByType = map[Type]fields{
 		Groups: []string{
 			MachinePoolBootstrappersGroup,
 		},
+		JoinCommand:    "machinepoollet --machine-pool-name=<machine-pool-name>",
+		CSRSubresource: "machinepoolclient",
+
+		CertificateOrganization:   computev1alpha1.MachinePoolsGroup,
+		CertificateUserNamePrefix: computev1alpha1.MachinePoolUserNamePrefix,
 	},
 	VolumePoolType: {
 		Description: "Bootstrap token for registering volume pools.",
@@ -65,6 +97,11 @@ var fieldsByType = map[Type]fields{
 		Groups: []string{
 			VolumePoolBootstrappersGroup,
 		},
+		JoinCommand:    "volumepoollet --volume-pool-name=<volume-pool-name>",
+		CSRSubresource: "volumepoolclient",
+
+		CertificateOrganization:   storagev1alpha1.VolumePoolsGroup,
+		CertificateUserNamePrefix: storagev1alpha1.VolumePoolUserNamePrefix,
 	},
 	BucketPoolType: {
 		Description: "Bootstrap token for registering bucket pools.",
@@ -75,6 +112,11 @@ var fieldsByType = map[Type]fields{
 		Groups: []string{
 			BucketPoolBootstrappersGroup,
 		},
+		JoinCommand:    "bucketpoollet --bucket-pool-name=<bucket-pool-name>",
+		CSRSubresource: "bucketpoolclient",
+
+		CertificateOrganization:   storagev1alpha1.BucketPoolsGroup,
+		CertificateUserNamePrefix: storagev1alpha1.BucketPoolUserNamePrefix,
 	},
 	NetworkPluginType: {
 		Description: "Bootstrap token for registering network plugins.",
@@ -85,6 +127,11 @@ var fieldsByType = map[Type]fields{
 		Groups: []string{
 			NetworkPluginBootstrappersGroup,
 		},
+		JoinCommand:    "<network-plugin>",
+		CSRSubresource: "networkpluginclient",
+
+		CertificateOrganization:   networkingv1alpha1.NetworkPluginsGroup,
+		CertificateUserNamePrefix: networkingv1alpha1.NetworkPluginUserNamePrefix,
 	},
 	APINetletType: {
 		Description: "Bootstrap token for registering apinetlets.",
@@ -95,6 +142,11 @@ var fieldsByType = map[Type]fields{
 		Groups: []string{
 			APINetletBootstrappersGroup,
 		},
+		JoinCommand:    "apinetlet",
+		CSRSubresource: "apinetletclient",
+
+		CertificateOrganization:   APINetletsGroup,
+		CertificateUserNamePrefix: APINetletUserNamePrefix,
 	},
 	MetalnetletType: {
 		Description: "Bootstrap token for registering metalnetlets.",
@@ -105,9 +157,23 @@ var fieldsByType = map[Type]fields{
 		Groups: []string{
 			MetalnetletBootstrappersGroup,
 		},
+		JoinCommand:    "metalnetlet",
+		CSRSubresource: "metalnetletclient",
+
+		CertificateOrganization:   MetalnetletsGroup,
+		CertificateUserNamePrefix: MetalnetletUserNamePrefix,
 	},
 }
 
+// JoinCommand returns the command line of the component registering with tokens of the given type.
+func JoinCommand(typ Type) (string, error) {
+	flds, ok := fieldsByType[typ]
+	if !ok {
+		return "", fmt.Errorf("unknown type %q", typ)
+	}
+	return flds.JoinCommand, nil
+}
+
 func AddTypeFields(bt *bootstraptoken.BootstrapToken, typ Type) error {
 	flds, ok := fieldsByType[typ]
 	if !ok {
@@ -133,3 +199,107 @@ func AddTypeFields(bt *bootstraptoken.BootstrapToken, typ Type) error {
 	}
 	return nil
 }
+
+// PoolGroup returns the extra group scoping tokens of the given type to the pool of the given name.
+// It is the first group of the type suffixed with the pool name, e.g.
+// system:bootstrappers:compute-ironcore-dev:machinepools:<pool-name>.
+func PoolGroup(typ Type, poolName string) (string, error) {
+	flds, ok := fieldsByType[typ]
+	if !ok {
+		return "", fmt.Errorf("unknown type %q", typ)
+	}
+
+	group := fmt.Sprintf("%s:%s", flds.Groups[0], poolName)
+	if err := util.ValidateBootstrapGroupName(group); err != nil {
+		return "", fmt.Errorf("pool name %q cannot be used in a bootstrap group: %w", poolName, err)
+	}
+	return group, nil
+}
+
+// AddPoolGroup scopes the given token of the given type to the pool of the given name by adding its PoolGroup.
+func AddPoolGroup(bt *bootstraptoken.BootstrapToken, typ Type, poolName string) error {
+	group, err := PoolGroup(typ, poolName)
+	if err != nil {
+		return err
+	}
+
+	if !sets.New(bt.Groups...).Has(group) {
+		bt.Groups = append(bt.Groups, group)
+	}
+	return nil
+}
+
+// PoolNamesOfGroups returns the names of the pools the given groups scope tokens of the given type to.
+// The result is sorted and empty if the groups do not scope the type to any pool.
+func PoolNamesOfGroups(typ Type, groups []string) []string {
+	flds, ok := fieldsByType[typ]
+	if !ok {
+		return nil
+	}
+
+	prefix := flds.Groups[0] + ":"
+	poolNames := sets.New[string]()
+	for _, group := range groups {
+		if poolName, ok := strings.CutPrefix(group, prefix); ok && poolName != "" {
+			poolNames.Insert(poolName)
+		}
+	}
+	return sets.List(poolNames)
+}
+
+// Groups returns the groups of tokens of the given type.
+func Groups(typ Type) ([]string, error) {
+	flds, ok := fieldsByType[typ]
+	if !ok {
+		return nil, fmt.Errorf("unknown type %q", typ)
+	}
+	return flds.Groups, nil
+}
+
+// CSRSubresource returns the certificatesigningrequests subresource of the component registering
+// with tokens of the given type. It is empty if the type does not declare one.
+func CSRSubresource(typ Type) (string, error) {
+	flds, ok := fieldsByType[typ]
+	if !ok {
+		return "", fmt.Errorf("unknown type %q", typ)
+	}
+	return flds.CSRSubresource, nil
+}
+
+// CertificateConvention returns the organization and common name prefix client certificates of the component
+// registering with tokens of the given type have to request. Both are empty if the type does not declare them.
+func CertificateConvention(typ Type) (organization, userNamePrefix string, err error) {
+	flds, ok := fieldsByType[typ]
+	if !ok {
+		return "", "", fmt.Errorf("unknown type %q", typ)
+	}
+	return flds.CertificateOrganization, flds.CertificateUserNamePrefix, nil
+}
+
+// HasType reports whether the given token carries all groups of the given type.
+func HasType(bt *bootstraptoken.BootstrapToken, typ Type) bool {
+	flds, ok := fieldsByType[typ]
+	if !ok {
+		return false
+	}
+	return sets.New(bt.Groups...).HasAll(flds.Groups...)
+}
+
+// TypesOf determines the types the given token was issued for by matching its groups
+// against the groups of the available types. The result is sorted.
+func TypesOf(bt *bootstraptoken.BootstrapToken) []Type {
+	return TypesOfGroups(bt.Groups)
+}
+
+// TypesOfGroups determines the types whose groups are all contained in the given groups,
+// e.g. the groups of a user authenticated with a bootstrap token. The result is sorted.
+func TypesOfGroups(groups []string) []Type {
+	groupSet := sets.New(groups...)
+	var types []Type
+	for _, typ := range sets.List(AvailableTypes) {
+		if groupSet.HasAll(fieldsByType[typ].Groups...) {
+			types = append(types, typ)
+		}
+	}
+	return types
+}
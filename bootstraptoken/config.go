@@ -0,0 +1,144 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstraptoken
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/cluster-bootstrap/token/util"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// TypesFileEnv is the environment variable to point to a TypesConfig file.
+	TypesFileEnv = "KUBECTL_IRONCORE_TOKEN_TYPES"
+
+	// DefaultTypesFileName is the name of the TypesConfig file in the kubeconfig directory.
+	DefaultTypesFileName = "ironcore-token-types.yaml"
+)
+
+// TypesConfig declares additional token types.
+type TypesConfig struct {
+	Types []TypeConfig `json:"types"`
+}
+
+// TypeConfig declares a single token type.
+type TypeConfig struct {
+	// Name is the name of the type.
+	Name Type `json:"name"`
+	// Description is the default description of tokens of the type.
+	Description string `json:"description,omitempty"`
+	// Usages are the usages of tokens of the type.
+	Usages []string `json:"usages,omitempty"`
+	// Groups are the groups of tokens of the type. At least one group is required.
+	Groups []string `json:"groups"`
+	// JoinCommand is the command line of the component registering with tokens of the type.
+	JoinCommand string `json:"joinCommand,omitempty"`
+	// CSRSubresource is the certificatesigningrequests subresource permission on which lets
+	// certificate signing requests of the component be auto-approved.
+	CSRSubresource string `json:"csrSubresource,omitempty"`
+	// CertificateOrganization is the organization client certificates of the component have to request.
+	CertificateOrganization string `json:"certificateOrganization,omitempty"`
+	// CertificateUserNamePrefix is the prefix of the common name client certificates of the component have to request.
+	CertificateUserNamePrefix string `json:"certificateUserNamePrefix,omitempty"`
+}
+
+// DefaultTypesFile returns the TypesConfig file to load and whether it was explicitly requested.
+// If TypesFileEnv is set, its value is used, otherwise DefaultTypesFileName in the kubeconfig directory.
+func DefaultTypesFile() (filename string, explicit bool) {
+	if filename := os.Getenv(TypesFileEnv); filename != "" {
+		return filename, true
+	}
+	return filepath.Join(clientcmd.RecommendedConfigDir, DefaultTypesFileName), false
+}
+
+// LoadDefaultTypesFile loads the DefaultTypesFile. A missing file is only an error if it was explicitly requested.
+// Built-in types may only be replaced if overrideBuiltin is set.
+func LoadDefaultTypesFile(overrideBuiltin bool) error {
+	filename, explicit := DefaultTypesFile()
+	if err := LoadTypesFile(filename, overrideBuiltin); err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// LoadTypesFile reads the TypesConfig from the given file and registers its types.
+// Built-in types may only be replaced if overrideBuiltin is set.
+func LoadTypesFile(filename string, overrideBuiltin bool) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("error reading token types file: %w", err)
+	}
+
+	cfg := &TypesConfig{}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return fmt.Errorf("error decoding token types file %s: %w", filename, err)
+	}
+
+	// Validate all types first, so that either all or none of the types of the file are registered.
+	for _, typCfg := range cfg.Types {
+		if err := validateTypeConfig(typCfg, overrideBuiltin); err != nil {
+			return fmt.Errorf("error registering token type from %s: %w", filename, err)
+		}
+	}
+	for _, typCfg := range cfg.Types {
+		registerType(typCfg)
+	}
+	return nil
+}
+
+// RegisterType validates the given TypeConfig and adds it to the available types.
+// A built-in type may only be replaced if overrideBuiltin is set.
+func RegisterType(cfg TypeConfig, overrideBuiltin bool) error {
+	if err := validateTypeConfig(cfg, overrideBuiltin); err != nil {
+		return err
+	}
+	registerType(cfg)
+	return nil
+}
+
+func validateTypeConfig(cfg TypeConfig, overrideBuiltin bool) error {
+	if cfg.Name == "" {
+		return fmt.Errorf("must specify type name")
+	}
+	if builtinTypes.Has(cfg.Name) && !overrideBuiltin {
+		return fmt.Errorf("type %s is a built-in type, specify --%s to replace it", cfg.Name, OverrideBuiltinTypesFlagName)
+	}
+	if len(cfg.Groups) == 0 {
+		return fmt.Errorf("type %s: must specify at least one group", cfg.Name)
+	}
+	for _, group := range cfg.Groups {
+		if err := util.ValidateBootstrapGroupName(group); err != nil {
+			return fmt.Errorf("type %s: %w", cfg.Name, err)
+		}
+	}
+	if err := util.ValidateUsages(cfg.Usages); err != nil {
+		return fmt.Errorf("type %s: %w", cfg.Name, err)
+	}
+	if (cfg.CertificateOrganization == "") != (cfg.CertificateUserNamePrefix == "") {
+		return fmt.Errorf("type %s: must specify both or none of certificate organization and user name prefix", cfg.Name)
+	}
+	return nil
+}
+
+func registerType(cfg TypeConfig) {
+	fieldsByType[cfg.Name] = fields{
+		Description:    cfg.Description,
+		Usages:         cfg.Usages,
+		Groups:         cfg.Groups,
+		JoinCommand:    cfg.JoinCommand,
+		CSRSubresource: cfg.CSRSubresource,
+
+		CertificateOrganization:   cfg.CertificateOrganization,
+		CertificateUserNamePrefix: cfg.CertificateUserNamePrefix,
+	}
+	AvailableTypes.Insert(cfg.Name)
+}
@@ -0,0 +1,120 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstraptoken
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ironcore-dev/kubectl-ironcore/utils/bootstraptoken"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+const (
+	// MaxTTLEnv is the environment variable to configure the default maximum TTL of created tokens.
+	MaxTTLEnv = "KUBECTL_IRONCORE_MAX_TOKEN_TTL"
+
+	MaxTTLFlagName        = "max-token-ttl"
+	AllowNoExpiryFlagName = "allow-no-expiry"
+)
+
+// ExpiryPolicy restricts the expiration of created tokens.
+type ExpiryPolicy struct {
+	// MaxTTL is the maximum time until created tokens expire. Zero means no maximum.
+	MaxTTL time.Duration
+	// AllowNoExpiry allows creating tokens that do not expire.
+	AllowNoExpiry bool
+}
+
+// AddFlags adds the MaxTTLFlagName and AllowNoExpiryFlagName flags to the given command.
+func (p *ExpiryPolicy) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().DurationVar(&p.MaxTTL, MaxTTLFlagName, 0, fmt.Sprintf("Maximum TTL of created tokens. Defaults to $%s if set, 0 means no maximum.", MaxTTLEnv))
+	cmd.Flags().BoolVar(&p.AllowNoExpiry, AllowNoExpiryFlagName, false, "Allow creating tokens that do not expire.")
+}
+
+// Complete defaults MaxTTL from MaxTTLEnv if the MaxTTLFlagName flag of the given command was not set.
+func (p *ExpiryPolicy) Complete(cmd *cobra.Command) error {
+	if cmd.Flags().Changed(MaxTTLFlagName) {
+		return nil
+	}
+
+	value := os.Getenv(MaxTTLEnv)
+	if value == "" {
+		return nil
+	}
+
+	maxTTL, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", MaxTTLEnv, err)
+	}
+	p.MaxTTL = maxTTL
+	return nil
+}
+
+// Check checks whether a token with the given expiration may be created at the given time.
+func (p *ExpiryPolicy) Check(expires *time.Time, now time.Time) error {
+	if expires == nil {
+		if !p.AllowNoExpiry {
+			return fmt.Errorf("token does not expire, specify an expiration or --%s", AllowNoExpiryFlagName)
+		}
+		return nil
+	}
+
+	ttl := expires.Sub(now)
+	if ttl <= 0 {
+		return fmt.Errorf("token expiration %s is not in the future", expires.UTC().Format(time.RFC3339))
+	}
+	if p.MaxTTL > 0 && ttl > p.MaxTTL {
+		return fmt.Errorf("token expires in %s, exceeding the maximum TTL of %s", duration.HumanDuration(ttl), p.MaxTTL)
+	}
+	return nil
+}
+
+// ExpiryFlags are the flags setting and restricting the expiration of a created token.
+type ExpiryFlags struct {
+	TTL     time.Duration
+	Expires string
+	ExpiryPolicy
+}
+
+// AddFlags adds the flags setting the expiration of a token and the ExpiryPolicy flags to the given command.
+func (f *ExpiryFlags) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().DurationVar(&f.TTL, "token-ttl", 0, "TTL for the token to expire.")
+	cmd.Flags().StringVar(&f.Expires, "token-expires", "", "Absolute time in RFC3339 format for the token to expire, e.g. 2024-01-31T12:00:00Z.")
+	cmd.MarkFlagsMutuallyExclusive("token-ttl", "token-expires")
+	f.ExpiryPolicy.AddFlags(cmd)
+}
+
+// Apply completes the ExpiryPolicy with the given command and returns the given template with the expiration
+// of the flags, checked against the ExpiryPolicy (see WithExpiry).
+func (f *ExpiryFlags) Apply(cmd *cobra.Command, template *bootstraptoken.BootstrapToken) (*bootstraptoken.BootstrapToken, error) {
+	if err := f.ExpiryPolicy.Complete(cmd); err != nil {
+		return nil, err
+	}
+	return f.WithExpiry(template)
+}
+
+// WithExpiry returns the given template with the expiration of the flags, checked against the ExpiryPolicy.
+// If no expiration is set via the flags, the expiration of the template is kept.
+// The ExpiryPolicy has to be completed already.
+func (f *ExpiryFlags) WithExpiry(template *bootstraptoken.BootstrapToken) (*bootstraptoken.BootstrapToken, error) {
+	now := time.Now()
+	switch {
+	case f.Expires != "":
+		expires, err := time.Parse(time.RFC3339, f.Expires)
+		if err != nil {
+			return nil, fmt.Errorf("invalid token expiration: %w", err)
+		}
+		template = template.WithExpires(expires)
+	case f.TTL > 0:
+		template = template.WithTTL(f.TTL)
+	}
+
+	if err := f.ExpiryPolicy.Check(template.Expires, now); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
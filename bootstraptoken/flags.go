@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstraptoken
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const TypeFlagName = "token-type"
+
+// AddTypeFlag adds the TypeFlagName flag to the given command, listing and completing the available types.
+func AddTypeFlag(cmd *cobra.Command, typ *Type, usage string) {
+	cmd.Flags().StringVar((*string)(typ), TypeFlagName, string(*typ), fmt.Sprintf("%s Available types: %v", usage, sets.List(AvailableTypes)))
+	_ = cmd.RegisterFlagCompletionFunc(TypeFlagName, CompleteTypes)
+}
+
+// CompleteTypes completes the available types with their descriptions.
+func CompleteTypes(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	var completions []string
+	for _, typ := range sets.List(AvailableTypes) {
+		completions = append(completions, fmt.Sprintf("%s\t%s", typ, fieldsByType[typ].Description))
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// OverrideBuiltinTypesFlagName is the name of the global flag allowing the types file to replace built-in types.
+const OverrideBuiltinTypesFlagName = "override-builtin-token-types"
+
+// AddOverrideBuiltinTypesFlag adds the OverrideBuiltinTypesFlagName flag to the given flag set.
+func AddOverrideBuiltinTypesFlag(fs *pflag.FlagSet, override *bool) {
+	fs.BoolVar(override, OverrideBuiltinTypesFlagName, *override, "Allow the token types file to replace built-in token types.")
+}
+
+// OverrideBuiltinTypesFromArgs reports whether the OverrideBuiltinTypesFlagName flag is set in the given arguments.
+// The types have to be loaded before the commands are created, so the flag is parsed ahead of the command line.
+func OverrideBuiltinTypesFromArgs(args []string) bool {
+	fs := pflag.NewFlagSet("", pflag.ContinueOnError)
+	fs.ParseErrorsWhitelist.UnknownFlags = true
+	fs.SetOutput(io.Discard)
+	fs.Usage = func() {}
+	// Define help so that it does not stop parsing.
+	fs.BoolP("help", "h", false, "")
+
+	var override bool
+	AddOverrideBuiltinTypesFlag(fs, &override)
+	_ = fs.Parse(args)
+	return override
+}
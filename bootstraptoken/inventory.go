@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstraptoken
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+)
+
+// InventoryEntry declares a token to create for a single pool.
+type InventoryEntry struct {
+	// Type is the type of the token.
+	Type Type `json:"type"`
+	// PoolName is the name of the pool registering with the token.
+	PoolName string `json:"poolName"`
+	// TTL is the time after which the token expires.
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+	// Expires is the absolute time the token expires. It is mutually exclusive with TTL.
+	// If neither is set, the token does not expire.
+	Expires *metav1.Time `json:"expires,omitempty"`
+	// Description is the description of the token. If unset, the default description of the type is used.
+	Description string `json:"description,omitempty"`
+}
+
+// String returns a human-readable identification of the entry.
+func (e InventoryEntry) String() string {
+	return fmt.Sprintf("%s pool %s", e.Type, e.PoolName)
+}
+
+// Validate validates the entry.
+func (e InventoryEntry) Validate() error {
+	if !AvailableTypes.Has(e.Type) {
+		return fmt.Errorf("unknown type %q", e.Type)
+	}
+	if errs := validation.IsDNS1123Subdomain(e.PoolName); len(errs) > 0 {
+		return fmt.Errorf("invalid pool name %q: %s", e.PoolName, strings.Join(errs, ", "))
+	}
+	if e.TTL != nil && e.TTL.Duration <= 0 {
+		return fmt.Errorf("ttl has to be positive")
+	}
+	if e.TTL != nil && e.Expires != nil {
+		return fmt.Errorf("cannot specify both ttl and expires")
+	}
+	return nil
+}
+
+// LoadInventoryFile reads the list of InventoryEntry from the given file.
+// The entries are not validated.
+func LoadInventoryFile(filename string) ([]InventoryEntry, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error reading inventory file: %w", err)
+	}
+
+	var entries []InventoryEntry
+	if err := yaml.UnmarshalStrict(data, &entries); err != nil {
+		return nil, fmt.Errorf("error decoding inventory file %s: %w", filename, err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,24 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"github.com/ironcore-dev/kubectl-ironcore/cmd/bootstrap/check"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+func Command(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bootstrap",
+		Short: "Inspect the token-based registration of ironcore components.",
+	}
+
+	cmd.AddCommand(
+		check.Command(f, streams),
+	)
+
+	return cmd
+}
@@ -0,0 +1,395 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package check
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ironcore-dev/kubectl-ironcore/bootstrapkubeconfig"
+	"github.com/ironcore-dev/kubectl-ironcore/bootstraprbac"
+	"github.com/ironcore-dev/kubectl-ironcore/bootstraptoken"
+	utilbootstraptoken "github.com/ironcore-dev/kubectl-ironcore/utils/bootstraptoken"
+	"github.com/spf13/cobra"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	bootstrapapi "k8s.io/cluster-bootstrap/token/api"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type Flags struct {
+	Factory cmdutil.Factory
+	Type    bootstraptoken.Type
+	genericclioptions.IOStreams
+}
+
+func NewFlags(f cmdutil.Factory, streams genericclioptions.IOStreams) *Flags {
+	return &Flags{
+		Factory:   f,
+		IOStreams: streams,
+	}
+}
+
+func (f *Flags) AddFlags(cmd *cobra.Command) {
+	bootstraptoken.AddTypeFlag(cmd, &f.Type, "Type of the tokens to check. If unset, the types of all existing tokens are checked.")
+}
+
+func (f *Flags) ToOptions() (*Options, error) {
+	if f.Type != "" && !bootstraptoken.AvailableTypes.Has(f.Type) {
+		return nil, fmt.Errorf("unknown type %q", f.Type)
+	}
+
+	cfg, err := f.Factory.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	newClient := func() (client.Client, error) {
+		return client.New(cfg, client.Options{})
+	}
+
+	return &Options{
+		Type:      f.Type,
+		NewClient: newClient,
+		IOStreams: f.IOStreams,
+	}, nil
+}
+
+type Options struct {
+	Type bootstraptoken.Type
+
+	NewClient func() (client.Client, error)
+	genericclioptions.IOStreams
+}
+
+func Command(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
+	flags := NewFlags(f, streams)
+
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Check the prerequisites for components to register with bootstrap tokens.",
+		Long: `Check the prerequisites for components to register with bootstrap tokens.
+
+For each token type, the following is checked:
+* An unexpired bootstrap token of the type exists.
+* The API server authenticates the token.
+* The token is authorized to create certificate signing requests for the component of the type.
+* The cluster-info ConfigMap exists, is readable anonymously and is signed for the token.
+
+The command fails if any check fails.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts, err := flags.ToOptions()
+			if err != nil {
+				return err
+			}
+
+			return Run(cmd.Context(), *opts)
+		},
+	}
+
+	flags.AddFlags(cmd)
+
+	return cmd
+}
+
+// result is the result of a single check.
+type result struct {
+	// Name is the name of the check.
+	Name string
+	// Message describes the outcome of the check.
+	Message string
+	// Failed reports whether the check failed.
+	Failed bool
+	// Hint describes how to remediate a failed check.
+	Hint string
+}
+
+func passed(name, format string, args ...any) result {
+	return result{Name: name, Message: fmt.Sprintf(format, args...)}
+}
+
+func failed(name, hint, format string, args ...any) result {
+	return result{Name: name, Message: fmt.Sprintf(format, args...), Failed: true, Hint: hint}
+}
+
+func Run(ctx context.Context, opts Options) error {
+	c, err := opts.NewClient()
+	if err != nil {
+		return err
+	}
+
+	secrets, err := utilbootstraptoken.ListSecrets(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	var tokens []*utilbootstraptoken.BootstrapToken
+	for i := range secrets {
+		token, err := utilbootstraptoken.FromSecret(&secrets[i])
+		if err != nil {
+			_, _ = fmt.Fprintf(opts.ErrOut, "Skipping secret %s/%s: %v\n", secrets[i].Namespace, secrets[i].Name, err)
+			continue
+		}
+		tokens = append(tokens, token)
+	}
+
+	types := []bootstraptoken.Type{opts.Type}
+	if opts.Type == "" {
+		typeSet := sets.New[bootstraptoken.Type]()
+		for _, token := range tokens {
+			typeSet.Insert(bootstraptoken.TypesOf(token)...)
+		}
+		types = sets.List(typeSet)
+	}
+	if len(types) == 0 {
+		printResults(opts.Out, "Bootstrap tokens", []result{
+			failed("Token", "Create a token with 'kubectl ironcore create token --token-type <type>'.",
+				"no bootstrap tokens of any type found"),
+		})
+		return fmt.Errorf("1 bootstrap check(s) failed")
+	}
+
+	var numFailed int
+	for _, typ := range types {
+		results := checkType(ctx, c, typ, tokens)
+		for _, res := range results {
+			if res.Failed {
+				numFailed++
+			}
+		}
+		printResults(opts.Out, string(typ), results)
+	}
+	if numFailed > 0 {
+		return fmt.Errorf("%d bootstrap check(s) failed", numFailed)
+	}
+	return nil
+}
+
+func printResults(w io.Writer, title string, results []result) {
+	_, _ = fmt.Fprintf(w, "%s:\n", title)
+	for _, res := range results {
+		status := "PASS"
+		if res.Failed {
+			status = "FAIL"
+		}
+		_, _ = fmt.Fprintf(w, "  [%s] %s: %s\n", status, res.Name, res.Message)
+		if res.Failed && res.Hint != "" {
+			_, _ = fmt.Fprintf(w, "         Hint: %s\n", res.Hint)
+		}
+	}
+}
+
+func checkType(ctx context.Context, c client.Client, typ bootstraptoken.Type, tokens []*utilbootstraptoken.BootstrapToken) []result {
+	now := time.Now()
+	token, res := checkToken(typ, tokens, now)
+	if token == nil {
+		return []result{res}
+	}
+
+	authRes, userInfo := checkAuthentication(ctx, c, typ, token)
+	return []result{
+		res,
+		authRes,
+		checkAuthorization(ctx, c, typ, token, userInfo),
+		checkClusterInfo(ctx, c, token),
+	}
+}
+
+// checkToken selects the unexpired token of the given type that expires last.
+func checkToken(typ bootstraptoken.Type, tokens []*utilbootstraptoken.BootstrapToken, now time.Time) (*utilbootstraptoken.BootstrapToken, result) {
+	const name = "Token"
+	var (
+		selected   *utilbootstraptoken.BootstrapToken
+		numExpired int
+	)
+	for _, token := range tokens {
+		if !bootstraptoken.HasType(token, typ) {
+			continue
+		}
+		if token.Expires != nil && !token.Expires.After(now) {
+			numExpired++
+			continue
+		}
+		if selected == nil || (selected.Expires != nil && (token.Expires == nil || token.Expires.After(*selected.Expires))) {
+			selected = token
+		}
+	}
+
+	hint := fmt.Sprintf("Create a token with 'kubectl ironcore create token --token-type %s'.", typ)
+	switch {
+	case selected == nil && numExpired > 0:
+		return nil, failed(name, hint, "all %d bootstrap token(s) of type %s have expired", numExpired, typ)
+	case selected == nil:
+		return nil, failed(name, hint, "no bootstrap token of type %s found", typ)
+	case selected.Expires == nil:
+		return selected, passed(name, "bootstrap token %s does not expire", selected.ID)
+	default:
+		return selected, passed(name, "bootstrap token %s expires in %s", selected.ID, duration.HumanDuration(selected.Expires.Sub(now)))
+	}
+}
+
+// checkAuthentication reviews the given token and returns the user info it authenticates as.
+// If the token could not be authenticated, the user info the bootstrap token authenticator would
+// return is returned.
+func checkAuthentication(
+	ctx context.Context,
+	c client.Client,
+	typ bootstraptoken.Type,
+	token *utilbootstraptoken.BootstrapToken,
+) (result, authenticationv1.UserInfo) {
+	const (
+		name = "Authentication"
+		hint = "Ensure the API server runs with '--enable-bootstrap-token-auth'."
+	)
+	expectedUserInfo := authenticationv1.UserInfo{
+		Username: bootstrapapi.BootstrapUserPrefix + token.ID,
+		Groups:   append([]string{bootstrapapi.BootstrapDefaultGroup, bootstraprbac.AllAuthenticatedGroup}, token.Groups...),
+	}
+
+	review := &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{
+			Token: fmt.Sprintf("%s.%s", token.ID, token.Secret),
+		},
+	}
+	if err := c.Create(ctx, review); err != nil {
+		return failed(name, "Ensure you are allowed to create tokenreviews.", "error reviewing token: %v", err), expectedUserInfo
+	}
+
+	status := review.Status
+	if !status.Authenticated {
+		msg := "token is not authenticated"
+		if status.Error != "" {
+			msg = fmt.Sprintf("%s: %s", msg, status.Error)
+		}
+		return failed(name, hint, "%s", msg), expectedUserInfo
+	}
+	if status.User.Username != expectedUserInfo.Username {
+		return failed(name, hint, "token authenticates as %s instead of %s", status.User.Username, expectedUserInfo.Username), expectedUserInfo
+	}
+
+	typeGroups, _ := bootstraptoken.Groups(typ)
+	if missing := sets.New(typeGroups...).Delete(status.User.Groups...); missing.Len() > 0 {
+		return failed(name, hint, "token is missing groups %v", sets.List(missing)), expectedUserInfo
+	}
+	return passed(name, "token authenticates as %s", status.User.Username), status.User
+}
+
+// checkAuthorization checks the given user info may create certificate signing requests for the given type.
+// Tokens scoped to a pool must not be allowed to have them auto-approved, as that would bypass the scope.
+func checkAuthorization(
+	ctx context.Context,
+	c client.Client,
+	typ bootstraptoken.Type,
+	token *utilbootstraptoken.BootstrapToken,
+	userInfo authenticationv1.UserInfo,
+) result {
+	const name = "Authorization"
+	reviewErrorHint := "Ensure you are allowed to create subjectaccessreviews."
+	poolNames := bootstraptoken.PoolNamesOfGroups(typ, token.Groups)
+	rbacFlags := fmt.Sprintf("--token-type %s", typ)
+	if len(poolNames) > 0 {
+		rbacFlags += " --pool-scoped"
+	}
+	hint := fmt.Sprintf("Create the RBAC with 'kubectl ironcore generate bootstrap-rbac %s --apply'.", rbacFlags)
+
+	allowed, err := reviewAccess(ctx, c, userInfo, authorizationv1.ResourceAttributes{
+		Group:    certificatesv1.GroupName,
+		Resource: "certificatesigningrequests",
+		Verb:     "create",
+	})
+	if err != nil {
+		return failed(name, reviewErrorHint, "error reviewing access: %v", err)
+	}
+	if !allowed {
+		return failed(name, hint, "token %s may not create certificatesigningrequests", token.ID)
+	}
+
+	csrSubresource, _ := bootstraptoken.CSRSubresource(typ)
+	if csrSubresource == "" {
+		return passed(name, "token %s may create certificate signing requests", token.ID)
+	}
+
+	allowed, err = reviewAccess(ctx, c, userInfo, authorizationv1.ResourceAttributes{
+		Group:       certificatesv1.GroupName,
+		Resource:    "certificatesigningrequests",
+		Subresource: csrSubresource,
+		Verb:        "create",
+	})
+	if err != nil {
+		return failed(name, reviewErrorHint, "error reviewing access: %v", err)
+	}
+	switch {
+	case len(poolNames) > 0 && allowed:
+		return failed(name, hint, "token %s is scoped to pool(s) %v but may have certificate signing requests for any pool auto-approved", token.ID, poolNames)
+	case len(poolNames) > 0:
+		return passed(name, "token %s may create certificate signing requests for pool(s) %v to be approved with 'kubectl ironcore certificate approve'", token.ID, poolNames)
+	case !allowed:
+		return failed(name, hint, "token %s may not create certificatesigningrequests/%s", token.ID, csrSubresource)
+	default:
+		return passed(name, "token %s may create certificate signing requests", token.ID)
+	}
+}
+
+// reviewAccess reviews whether the given user info is allowed the given resource attributes.
+func reviewAccess(ctx context.Context, c client.Client, userInfo authenticationv1.UserInfo, attrs authorizationv1.ResourceAttributes) (bool, error) {
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &attrs,
+			User:               userInfo.Username,
+			Groups:             userInfo.Groups,
+			UID:                userInfo.UID,
+		},
+	}
+	if err := c.Create(ctx, review); err != nil {
+		return false, err
+	}
+	return review.Status.Allowed, nil
+}
+
+// checkClusterInfo checks the cluster-info ConfigMap exists, may be read anonymously and is signed for the given token.
+func checkClusterInfo(ctx context.Context, c client.Client, token *utilbootstraptoken.BootstrapToken) result {
+	const name = "Cluster info"
+
+	clusterInfo := &corev1.ConfigMap{}
+	clusterInfoKey := client.ObjectKey{Namespace: metav1.NamespacePublic, Name: bootstrapapi.ConfigMapClusterInfo}
+	if err := c.Get(ctx, clusterInfoKey, clusterInfo); err != nil {
+		return failed(name, "Ensure the cluster-info ConfigMap is published in the kube-public namespace, e.g. as done by kubeadm.",
+			"error getting cluster-info ConfigMap: %v", err)
+	}
+
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: metav1.NamespacePublic,
+				Resource:  "configmaps",
+				Name:      bootstrapapi.ConfigMapClusterInfo,
+				Verb:      "get",
+			},
+			User:   bootstraprbac.AnonymousUser,
+			Groups: []string{bootstraprbac.AllUnauthenticatedGroup},
+		},
+	}
+	if err := c.Create(ctx, review); err != nil {
+		return failed(name, "Ensure you are allowed to create subjectaccessreviews.", "error reviewing access: %v", err)
+	}
+	if !review.Status.Allowed {
+		return failed(name, "Create the RBAC with 'kubectl ironcore generate bootstrap-rbac --cluster-info --apply'.",
+			"cluster-info ConfigMap may not be read anonymously")
+	}
+
+	if _, err := bootstrapkubeconfig.VerifyClusterInfo(clusterInfo, token); err != nil {
+		return failed(name, "Ensure the bootstrapsigner controller of the kube-controller-manager is enabled, e.g. via '--controllers=*,bootstrapsigner'.",
+			"%v", err)
+	}
+	return passed(name, "cluster-info ConfigMap is signed for token %s", token.ID)
+}
@@ -0,0 +1,286 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package approve
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/ironcore-dev/kubectl-ironcore/bootstrapcsr"
+	"github.com/spf13/cobra"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/kubernetes/scheme"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type Flags struct {
+	Factory    cmdutil.Factory
+	AllValid   bool
+	Watch      bool
+	PolicyFile string
+	PrintFlags *genericclioptions.PrintFlags
+	genericclioptions.IOStreams
+}
+
+func NewFlags(f cmdutil.Factory, streams genericclioptions.IOStreams) *Flags {
+	printFlags := genericclioptions.NewPrintFlags("approved").
+		WithTypeSetter(scheme.Scheme)
+
+	return &Flags{
+		Factory:    f,
+		PrintFlags: printFlags,
+		IOStreams:  streams,
+	}
+}
+
+func (f *Flags) AddFlags(cmd *cobra.Command) {
+	cmdutil.AddDryRunFlag(cmd)
+	cmd.Flags().BoolVar(&f.AllValid, "all-valid", false, "Approve all pending certificate signing requests of users of ironcore bootstrap token types that are valid.")
+	cmd.Flags().BoolVarP(&f.Watch, "watch", "w", false, "Keep watching certificate signing requests and approve all valid ones until interrupted.")
+	cmd.Flags().StringVar(&f.PolicyFile, "policy-file", "", "Policy file restricting the token types, pool names and certificate durations to approve.")
+	f.PrintFlags.AddFlags(cmd)
+}
+
+func (f *Flags) ToOptions(cmd *cobra.Command, args []string) (*Options, error) {
+	if len(args) == 0 && !f.AllValid && !f.Watch {
+		return nil, fmt.Errorf("must specify either certificate signing request names, --all-valid or --watch")
+	}
+	if len(args) > 0 && (f.AllValid || f.Watch) {
+		return nil, fmt.Errorf("cannot specify certificate signing request names together with --all-valid or --watch")
+	}
+
+	policy := &bootstrapcsr.Policy{}
+	if f.PolicyFile != "" {
+		var err error
+		policy, err = bootstrapcsr.LoadPolicyFile(f.PolicyFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	dryRunStrategy, err := cmdutil.GetDryRunStrategy(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	cmdutil.PrintFlagsWithDryRunStrategy(f.PrintFlags, dryRunStrategy)
+	printer, err := f.PrintFlags.ToPrinter()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := f.Factory.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	newClient := func() (client.WithWatch, error) {
+		return client.NewWithWatch(cfg, client.Options{})
+	}
+
+	return &Options{
+		Names:     args,
+		AllValid:  f.AllValid,
+		Watch:     f.Watch,
+		Policy:    policy,
+		DryRun:    dryRunStrategy,
+		Printer:   printer,
+		NewClient: newClient,
+		IOStreams: f.IOStreams,
+	}, nil
+}
+
+type Options struct {
+	Names     []string
+	AllValid  bool
+	Watch     bool
+	Policy    *bootstrapcsr.Policy
+	DryRun    cmdutil.DryRunStrategy
+	Printer   printers.ResourcePrinter
+	NewClient func() (client.WithWatch, error)
+	genericclioptions.IOStreams
+}
+
+func Command(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
+	flags := NewFlags(f, streams)
+
+	cmd := &cobra.Command{
+		Use:   "approve (<name>... | --all-valid | --watch)",
+		Short: "Approve certificate signing requests of components registering with bootstrap tokens.",
+		Long: `Approve certificate signing requests of components registering with bootstrap tokens.
+
+A certificate signing request is only approved if it was made by a bootstrap token user whose groups
+match a single token type and if its signer, usages, organization and common name follow the conventions
+of that type. Certificate signing requests not following them are reported with the reason.
+
+With --watch, certificate signing requests are watched and approved as they come in until the
+command is interrupted, which makes it usable as a long-running auto-approver, e.g. in a pod.
+Decisions are logged instead of printed.
+
+A policy file further restricts which valid certificate signing requests are approved:
+
+  types: [MachinePool, VolumePool]
+  poolNamePattern: ^node-[0-9]+$
+  maxDuration: 720h
+  requirePoolScope: true`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts, err := flags.ToOptions(cmd, args)
+			if err != nil {
+				return err
+			}
+
+			return Run(cmd.Context(), *opts)
+		},
+	}
+
+	flags.AddFlags(cmd)
+
+	return cmd
+}
+
+func Run(ctx context.Context, opts Options) error {
+	c, err := opts.NewClient()
+	if err != nil {
+		return err
+	}
+
+	if opts.Watch {
+		return runWatch(ctx, c, opts)
+	}
+
+	var csrs []certificatesv1.CertificateSigningRequest
+	if opts.AllValid {
+		csrs, err = bootstrapcsr.ListPending(ctx, c)
+		if err != nil {
+			return err
+		}
+	} else {
+		for _, name := range opts.Names {
+			csr := &certificatesv1.CertificateSigningRequest{}
+			if err := c.Get(ctx, client.ObjectKey{Name: name}, csr); err != nil {
+				return fmt.Errorf("error getting certificate signing request %s: %w", name, err)
+			}
+			csrs = append(csrs, *csr)
+		}
+	}
+
+	var updateOpts []client.SubResourceUpdateOption
+	if opts.DryRun == cmdutil.DryRunServer {
+		updateOpts = append(updateOpts, client.DryRunAll)
+	}
+
+	var numRejected int
+	for i := range csrs {
+		csr := &csrs[i]
+		if !bootstrapcsr.IsPending(csr) {
+			_, _ = fmt.Fprintf(opts.ErrOut, "Not approving certificate signing request %s: it is not pending\n", csr.Name)
+			numRejected++
+			continue
+		}
+
+		req, err := validate(csr, opts.Policy)
+		if err != nil {
+			_, _ = fmt.Fprintf(opts.ErrOut, "Not approving certificate signing request %s: %v\n", csr.Name, err)
+			if !opts.AllValid {
+				numRejected++
+			}
+			continue
+		}
+
+		if err := approve(ctx, c, csr, req, opts.DryRun, updateOpts); err != nil {
+			_, _ = fmt.Fprintf(opts.ErrOut, "Error approving certificate signing request %s: %v\n", csr.Name, err)
+			numRejected++
+			continue
+		}
+
+		if err := opts.Printer.PrintObj(csr, opts.Out); err != nil {
+			return fmt.Errorf("error printing object: %w", err)
+		}
+	}
+	if numRejected > 0 {
+		return fmt.Errorf("%d certificate signing request(s) were not approved", numRejected)
+	}
+	return nil
+}
+
+func validate(csr *certificatesv1.CertificateSigningRequest, policy *bootstrapcsr.Policy) (*bootstrapcsr.Request, error) {
+	req, err := bootstrapcsr.Validate(csr)
+	if err != nil {
+		return nil, err
+	}
+	if err := policy.Check(csr, req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func approve(
+	ctx context.Context,
+	c client.Client,
+	csr *certificatesv1.CertificateSigningRequest,
+	req *bootstrapcsr.Request,
+	dryRun cmdutil.DryRunStrategy,
+	updateOpts []client.SubResourceUpdateOption,
+) error {
+	message := fmt.Sprintf("Approved %s client certificate for pool %s.", req.Type, req.PoolName)
+	if dryRun == cmdutil.DryRunClient {
+		bootstrapcsr.SetApproved(csr, message)
+		return nil
+	}
+	return bootstrapcsr.Approve(ctx, c, csr, message, updateOpts...)
+}
+
+func runWatch(ctx context.Context, c client.WithWatch, opts Options) error {
+	log := logr.FromContextOrDiscard(ctx)
+
+	var updateOpts []client.SubResourceUpdateOption
+	if opts.DryRun == cmdutil.DryRunServer {
+		updateOpts = append(updateOpts, client.DryRunAll)
+	}
+
+	// handled keeps track of the pending certificate signing requests already rejected or approved
+	// (in dry run mode, approved ones stay pending) to not handle them again on every update.
+	// They are forgotten once they are no longer pending or deleted.
+	handled := sets.New[types.UID]()
+	handle := func(eventType watch.EventType, csr *certificatesv1.CertificateSigningRequest) bool {
+		if eventType == watch.Deleted || !bootstrapcsr.IsPending(csr) {
+			handled.Delete(csr.UID)
+			return false
+		}
+		if !bootstrapcsr.IsIroncore(csr) || handled.Has(csr.UID) {
+			return false
+		}
+		log := log.WithValues("CertificateSigningRequest", csr.Name, "Username", csr.Spec.Username)
+
+		req, err := validate(csr, opts.Policy)
+		if err != nil {
+			log.Info("Not approving certificate signing request", "Reason", err.Error())
+			handled.Insert(csr.UID)
+			return false
+		}
+
+		log = log.WithValues("Type", req.Type, "PoolName", req.PoolName)
+		if err := approve(ctx, c, csr, req, opts.DryRun, updateOpts); err != nil {
+			log.Error(err, "Error approving certificate signing request")
+			return false
+		}
+		log.Info("Approved certificate signing request", "DryRun", opts.DryRun != cmdutil.DryRunNone)
+		handled.Insert(csr.UID)
+		return false
+	}
+
+	log.Info("Watching certificate signing requests")
+	if err := bootstrapcsr.Watch(ctx, c, handle); err != nil && ctx.Err() == nil {
+		return err
+	}
+	log.Info("Stopped watching certificate signing requests")
+	return nil
+}
@@ -0,0 +1,26 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package certificate
+
+import (
+	"github.com/ironcore-dev/kubectl-ironcore/cmd/certificate/approve"
+	"github.com/ironcore-dev/kubectl-ironcore/cmd/certificate/deny"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+func Command(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "certificate",
+		Short: "Modify certificate signing requests of components registering with bootstrap tokens.",
+	}
+
+	cmd.AddCommand(
+		approve.Command(f, streams),
+		deny.Command(f, streams),
+	)
+
+	return cmd
+}
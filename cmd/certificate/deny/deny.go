@@ -0,0 +1,182 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package deny
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ironcore-dev/kubectl-ironcore/bootstrapcsr"
+	"github.com/spf13/cobra"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/kubernetes/scheme"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const defaultMessage = "Denied by kubectl ironcore."
+
+type Flags struct {
+	Factory    cmdutil.Factory
+	AllInvalid bool
+	Message    string
+	PrintFlags *genericclioptions.PrintFlags
+	genericclioptions.IOStreams
+}
+
+func NewFlags(f cmdutil.Factory, streams genericclioptions.IOStreams) *Flags {
+	printFlags := genericclioptions.NewPrintFlags("denied").
+		WithTypeSetter(scheme.Scheme)
+
+	return &Flags{
+		Factory:    f,
+		PrintFlags: printFlags,
+		IOStreams:  streams,
+	}
+}
+
+func (f *Flags) AddFlags(cmd *cobra.Command) {
+	cmdutil.AddDryRunFlag(cmd)
+	cmd.Flags().BoolVar(&f.AllInvalid, "all-invalid", false, "Deny all pending certificate signing requests of users of ironcore bootstrap token types that are invalid.")
+	cmd.Flags().StringVar(&f.Message, "message", "", "Message to deny with. If unset, the reason the certificate signing request is invalid is used.")
+	f.PrintFlags.AddFlags(cmd)
+}
+
+func (f *Flags) ToOptions(cmd *cobra.Command, args []string) (*Options, error) {
+	if len(args) == 0 && !f.AllInvalid {
+		return nil, fmt.Errorf("must specify either certificate signing request names or --all-invalid")
+	}
+	if len(args) > 0 && f.AllInvalid {
+		return nil, fmt.Errorf("cannot specify certificate signing request names together with --all-invalid")
+	}
+
+	dryRunStrategy, err := cmdutil.GetDryRunStrategy(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	cmdutil.PrintFlagsWithDryRunStrategy(f.PrintFlags, dryRunStrategy)
+	printer, err := f.PrintFlags.ToPrinter()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := f.Factory.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	newClient := func() (client.Client, error) {
+		return client.New(cfg, client.Options{})
+	}
+
+	return &Options{
+		Names:      args,
+		AllInvalid: f.AllInvalid,
+		Message:    f.Message,
+		DryRun:     dryRunStrategy,
+		Printer:    printer,
+		NewClient:  newClient,
+		IOStreams:  f.IOStreams,
+	}, nil
+}
+
+type Options struct {
+	Names      []string
+	AllInvalid bool
+	Message    string
+	DryRun     cmdutil.DryRunStrategy
+	Printer    printers.ResourcePrinter
+	NewClient  func() (client.Client, error)
+	genericclioptions.IOStreams
+}
+
+func Command(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
+	flags := NewFlags(f, streams)
+
+	cmd := &cobra.Command{
+		Use:   "deny (<name>... | --all-invalid)",
+		Short: "Deny certificate signing requests of components registering with bootstrap tokens.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts, err := flags.ToOptions(cmd, args)
+			if err != nil {
+				return err
+			}
+
+			return Run(cmd.Context(), *opts)
+		},
+	}
+
+	flags.AddFlags(cmd)
+
+	return cmd
+}
+
+func Run(ctx context.Context, opts Options) error {
+	c, err := opts.NewClient()
+	if err != nil {
+		return err
+	}
+
+	var csrs []certificatesv1.CertificateSigningRequest
+	if opts.AllInvalid {
+		csrs, err = bootstrapcsr.ListPending(ctx, c)
+		if err != nil {
+			return err
+		}
+	} else {
+		for _, name := range opts.Names {
+			csr := &certificatesv1.CertificateSigningRequest{}
+			if err := c.Get(ctx, client.ObjectKey{Name: name}, csr); err != nil {
+				return fmt.Errorf("error getting certificate signing request %s: %w", name, err)
+			}
+			csrs = append(csrs, *csr)
+		}
+	}
+
+	var updateOpts []client.SubResourceUpdateOption
+	if opts.DryRun == cmdutil.DryRunServer {
+		updateOpts = append(updateOpts, client.DryRunAll)
+	}
+
+	var numSkipped int
+	for i := range csrs {
+		csr := &csrs[i]
+		if !bootstrapcsr.IsPending(csr) {
+			_, _ = fmt.Fprintf(opts.ErrOut, "Not denying certificate signing request %s: it is not pending\n", csr.Name)
+			numSkipped++
+			continue
+		}
+
+		message := opts.Message
+		_, validationErr := bootstrapcsr.Validate(csr)
+		switch {
+		case validationErr == nil && opts.AllInvalid:
+			continue
+		case message != "":
+		case validationErr != nil:
+			message = fmt.Sprintf("Invalid certificate signing request: %v.", validationErr)
+		default:
+			message = defaultMessage
+		}
+
+		if opts.DryRun == cmdutil.DryRunClient {
+			bootstrapcsr.SetDenied(csr, message)
+		} else if err := bootstrapcsr.Deny(ctx, c, csr, message, updateOpts...); err != nil {
+			_, _ = fmt.Fprintf(opts.ErrOut, "Error denying certificate signing request %s: %v\n", csr.Name, err)
+			numSkipped++
+			continue
+		}
+
+		if err := opts.Printer.PrintObj(csr, opts.Out); err != nil {
+			return fmt.Errorf("error printing object: %w", err)
+		}
+	}
+	if numSkipped > 0 {
+		return fmt.Errorf("%d certificate signing request(s) were not denied", numSkipped)
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package console
+
+import (
+	"github.com/ironcore-dev/kubectl-ironcore/cmd/console/replay"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+func Command(streams genericclioptions.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "console",
+		Short: "Work with recorded machine console sessions.",
+	}
+
+	cmd.AddCommand(
+		replay.Command(streams),
+	)
+
+	return cmd
+}
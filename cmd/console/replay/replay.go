@@ -0,0 +1,150 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package replay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/ironcore-dev/kubectl-ironcore/asciicast"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+type Flags struct {
+	Speed         float64
+	IdleTimeLimit time.Duration
+	genericclioptions.IOStreams
+}
+
+func NewFlags(streams genericclioptions.IOStreams) *Flags {
+	return &Flags{
+		Speed:     1,
+		IOStreams: streams,
+	}
+}
+
+func (f *Flags) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().Float64Var(&f.Speed, "speed", f.Speed, "Playback speed, e.g. 2 plays the recording twice as fast.")
+	cmd.Flags().DurationVar(&f.IdleTimeLimit, "idle-time-limit", 0, "Limit pauses between output to this duration. 0 means no limit.")
+}
+
+func (f *Flags) ToOptions(args []string) (*Options, error) {
+	if f.Speed <= 0 {
+		return nil, fmt.Errorf("speed has to be positive")
+	}
+	if f.IdleTimeLimit < 0 {
+		return nil, fmt.Errorf("idle time limit must not be negative")
+	}
+
+	return &Options{
+		Filename:      args[0],
+		Speed:         f.Speed,
+		IdleTimeLimit: f.IdleTimeLimit,
+		IOStreams:     f.IOStreams,
+	}, nil
+}
+
+type Options struct {
+	Filename string
+
+	// Speed is the factor the recorded delays between output are divided by.
+	Speed float64
+	// IdleTimeLimit is the maximum delay between output. Zero means no limit.
+	IdleTimeLimit time.Duration
+
+	genericclioptions.IOStreams
+}
+
+func Command(streams genericclioptions.IOStreams) *cobra.Command {
+	flags := NewFlags(streams)
+
+	cmd := &cobra.Command{
+		Use:   "replay <file>",
+		Short: "Replay a machine console session recorded with 'exec --record'.",
+		Long: `Replay a machine console session recorded with 'exec --record'.
+
+The recording is played back to stdout at the recorded pace, adjusted by --speed.
+Recorded input and terminal resizes are not replayed.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts, err := flags.ToOptions(args)
+			if err != nil {
+				return err
+			}
+
+			return Run(cmd.Context(), *opts)
+		},
+	}
+
+	flags.AddFlags(cmd)
+
+	return cmd
+}
+
+func Run(ctx context.Context, opts Options) error {
+	f, err := os.Open(opts.Filename)
+	if err != nil {
+		return fmt.Errorf("error opening recording: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	_, r, err := asciicast.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("error reading recording %s: %w", opts.Filename, err)
+	}
+
+	return Replay(ctx, r, opts.Out, opts.Speed, opts.IdleTimeLimit)
+}
+
+// Replay writes the output events read from r to out, pacing them as recorded.
+// The delays between events are divided by speed and capped at idleTimeLimit if it is positive.
+func Replay(ctx context.Context, r *asciicast.Reader, out io.Writer, speed float64, idleTimeLimit time.Duration) error {
+	var last time.Duration
+	for {
+		event, err := r.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if event.Type != asciicast.EventTypeOutput {
+			continue
+		}
+
+		delay := event.Time - last
+		last = event.Time
+		if idleTimeLimit > 0 && delay > idleTimeLimit {
+			delay = idleTimeLimit
+		}
+		if err := sleep(ctx, time.Duration(float64(delay)/speed)); err != nil {
+			return err
+		}
+
+		if _, err := io.WriteString(out, event.Data); err != nil {
+			return err
+		}
+	}
+}
+
+// sleep waits for the given duration or until the context is done. It is replaced in tests.
+var sleep = func(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package replay
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ironcore-dev/kubectl-ironcore/asciicast"
+)
+
+const recording = `{"version": 2, "width": 80, "height": 24}
+[1.0, "o", "a"]
+[1.5, "i", "x"]
+[2.0, "r", "100x30"]
+[3.0, "o", "b"]
+[13.0, "o", "c"]
+`
+
+func TestReplay(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		speed          float64
+		idleTimeLimit  time.Duration
+		expectedDelays []time.Duration
+	}{
+		{
+			name:           "recorded pace",
+			speed:          1,
+			expectedDelays: []time.Duration{time.Second, 2 * time.Second, 10 * time.Second},
+		},
+		{
+			name:           "double speed",
+			speed:          2,
+			expectedDelays: []time.Duration{500 * time.Millisecond, time.Second, 5 * time.Second},
+		},
+		{
+			name:           "idle time limit",
+			speed:          1,
+			idleTimeLimit:  1500 * time.Millisecond,
+			expectedDelays: []time.Duration{time.Second, 1500 * time.Millisecond, 1500 * time.Millisecond},
+		},
+		{
+			name:           "idle time limit before speed",
+			speed:          2,
+			idleTimeLimit:  4 * time.Second,
+			expectedDelays: []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var delays []time.Duration
+			defer func(orig func(context.Context, time.Duration) error) { sleep = orig }(sleep)
+			sleep = func(_ context.Context, d time.Duration) error {
+				delays = append(delays, d)
+				return nil
+			}
+
+			_, r, err := asciicast.NewReader(strings.NewReader(recording))
+			if err != nil {
+				t.Fatalf("error reading recording: %v", err)
+			}
+
+			out := &bytes.Buffer{}
+			if err := Replay(context.Background(), r, out, tc.speed, tc.idleTimeLimit); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out.String() != "abc" {
+				t.Errorf("expected only output events %q, got %q", "abc", out.String())
+			}
+			if !reflect.DeepEqual(delays, tc.expectedDelays) {
+				t.Errorf("expected delays %v, got %v", tc.expectedDelays, delays)
+			}
+		})
+	}
+}
+
+func TestReplayCanceled(t *testing.T) {
+	_, r, err := asciicast.NewReader(strings.NewReader(recording))
+	if err != nil {
+		t.Fatalf("error reading recording: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	out := &bytes.Buffer{}
+	if err := Replay(ctx, r, out, 1, 0); err != context.Canceled {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected no output, got %q", out.String())
+	}
+}
@@ -5,6 +5,7 @@ package create
 
 import (
 	"github.com/ironcore-dev/kubectl-ironcore/cmd/create/token"
+	"github.com/ironcore-dev/kubectl-ironcore/cmd/create/tokens"
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
@@ -18,6 +19,7 @@ func Command(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Comm
 
 	cmd.AddCommand(
 		token.Command(f, streams),
+		tokens.Command(f, streams),
 	)
 
 	return cmd
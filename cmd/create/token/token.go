@@ -6,26 +6,37 @@ package token
 import (
 	"context"
 	"fmt"
-	"time"
+	"io"
 
-	"github.com/ironcore-dev/kubectl-ironcore/api"
+	"github.com/ironcore-dev/kubectl-ironcore/bootstrapcsr"
 	"github.com/ironcore-dev/kubectl-ironcore/bootstraptoken"
+	"github.com/ironcore-dev/kubectl-ironcore/joincommand"
+	"github.com/ironcore-dev/kubectl-ironcore/utils/apply"
 	utilbootstraptoken "github.com/ironcore-dev/kubectl-ironcore/utils/bootstraptoken"
+	"github.com/ironcore-dev/kubectl-ironcore/utils/kubeconfig"
 	"github.com/spf13/cobra"
-	"k8s.io/apimachinery/pkg/util/sets"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/cli-runtime/pkg/printers"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 type Flags struct {
-	Factory    cmdutil.Factory
-	Template   utilbootstraptoken.BootstrapToken
-	Type       bootstraptoken.Type
-	TTL        time.Duration
-	PrintFlags *genericclioptions.PrintFlags
+	Factory                  cmdutil.Factory
+	Template                 utilbootstraptoken.BootstrapToken
+	Type                     bootstraptoken.Type
+	PoolName                 string
+	Expiry                   bootstraptoken.ExpiryFlags
+	PrintBootstrapKubeconfig bool
+	BootstrapKubeconfigFile  string
+	PrintJoinCommand         bool
+	JoinCommandTemplate      string
+	OneTime                  bool
+	PrintFlags               *genericclioptions.PrintFlags
 	genericclioptions.IOStreams
 }
 
@@ -42,32 +53,51 @@ func NewFlags(f cmdutil.Factory, streams genericclioptions.IOStreams) *Flags {
 
 func (f *Flags) AddFlags(cmd *cobra.Command) {
 	cmdutil.AddDryRunFlag(cmd)
-	cmd.Flags().StringVar((*string)(&f.Type), "token-type", "", fmt.Sprintf("Token type fields to add. Available types: %v", sets.List(bootstraptoken.AvailableTypes)))
+	bootstraptoken.AddTypeFlag(cmd, &f.Type, "Token type fields to add.")
+	cmd.Flags().StringVar(&f.PoolName, "pool-name", "", "Name of the pool to scope the token to. Requires --token-type.")
 	cmd.Flags().StringVar(&f.Template.ID, "token-id", "", "Token ID to use to generate.")
 	cmd.Flags().StringVar(&f.Template.Secret, "token-secret", "", "Token secret to use to generate.")
 	cmd.Flags().StringVar(&f.Template.Description, "token-description", "", "Token description to use to generate.")
 	cmd.Flags().StringSliceVar(&f.Template.Groups, "token-groups", nil, "Additional token groups.")
 	cmd.Flags().StringSliceVar(&f.Template.Usages, "token-usages", nil, "Additional token usages.")
-	cmd.Flags().DurationVar(&f.TTL, "token-ttl", 0, "TTL for the token to expire. If unset, token will not expire.")
+	f.Expiry.AddFlags(cmd)
+	cmd.Flags().BoolVar(&f.PrintBootstrapKubeconfig, "print-bootstrap-kubeconfig", false, "Print a bootstrap kubeconfig for the token generated from the current kubeconfig. The created secret is then reported on stderr.")
+	cmd.Flags().StringVar(&f.BootstrapKubeconfigFile, "bootstrap-kubeconfig-file", "", "Write a bootstrap kubeconfig for the token generated from the current kubeconfig to the given file, replacing it with a file only readable by the owner.")
+	cmd.Flags().BoolVar(&f.PrintJoinCommand, "print-join-command", false, "Print the command the component of the token type needs to register with the token. The created secret is then reported on stderr.")
+	cmd.Flags().StringVar(&f.JoinCommandTemplate, "join-command-template", "", "Go template to render the join command with. If unset, a default template for the token type is used.")
+	cmd.Flags().BoolVar(&f.OneTime, "one-time", false, "Wait for the first certificate signing request made with the token and delete the token as soon as it is submitted.")
 	f.PrintFlags.AddFlags(cmd)
 }
 
 func (f *Flags) ToOptions(cmd *cobra.Command) (*Options, error) {
-	template := f.Template
-	if f.TTL > 0 {
-		template = *f.Template.WithTTL(f.TTL)
+	expiring, err := f.Expiry.Apply(cmd, &f.Template)
+	if err != nil {
+		return nil, err
 	}
+	template := *expiring
 	if f.Type != "" {
 		if err := bootstraptoken.AddTypeFields(&template, f.Type); err != nil {
 			return nil, err
 		}
 	}
+	if f.PoolName != "" {
+		if f.Type == "" {
+			return nil, fmt.Errorf("must specify --token-type to scope the token to a pool")
+		}
+		if err := bootstraptoken.AddPoolGroup(&template, f.Type, f.PoolName); err != nil {
+			return nil, err
+		}
+	}
 
 	dryRunStrategy, err := cmdutil.GetDryRunStrategy(cmd)
 	if err != nil {
 		return nil, err
 	}
 
+	if f.OneTime && dryRunStrategy != cmdutil.DryRunNone {
+		return nil, fmt.Errorf("cannot use --one-time with --dry-run")
+	}
+
 	cmdutil.PrintFlagsWithDryRunStrategy(f.PrintFlags, dryRunStrategy)
 	printer, err := f.PrintFlags.ToPrinter()
 	if err != nil {
@@ -79,22 +109,57 @@ func (f *Flags) ToOptions(cmd *cobra.Command) (*Options, error) {
 		return nil, err
 	}
 
+	printBootstrapKubeconfig := f.PrintBootstrapKubeconfig || f.BootstrapKubeconfigFile != ""
+	if printBootstrapKubeconfig && f.BootstrapKubeconfigFile == "" && f.PrintJoinCommand {
+		return nil, fmt.Errorf("cannot print both bootstrap kubeconfig and join command, use --bootstrap-kubeconfig-file")
+	}
+
+	joinCommandTemplate := f.JoinCommandTemplate
+	if f.PrintJoinCommand && joinCommandTemplate == "" {
+		if f.Type == "" {
+			return nil, fmt.Errorf("must specify --token-type or --join-command-template to print the join command")
+		}
+		if joinCommand, err := bootstraptoken.JoinCommand(f.Type); err != nil || joinCommand == "" {
+			return nil, fmt.Errorf("token type %s has no join command, must specify --join-command-template", f.Type)
+		}
+		joinCommandTemplate = joincommand.DefaultTemplate
+	}
+
+	var startingCfg *clientcmdapi.Config
+	if printBootstrapKubeconfig || f.PrintJoinCommand {
+		rawCfg, err := f.Factory.ToRawKubeConfigLoader().RawConfig()
+		if err != nil {
+			return nil, err
+		}
+		if contextName, _ := cmd.Flags().GetString(clientcmd.FlagContext); contextName != "" {
+			rawCfg.CurrentContext = contextName
+		}
+		startingCfg = &rawCfg
+	}
+
 	cfg, err := f.Factory.ToRESTConfig()
 	if err != nil {
 		return nil, err
 	}
 
-	newClient := func() (client.Client, error) {
-		return client.New(cfg, client.Options{})
+	newClient := func() (client.WithWatch, error) {
+		return client.NewWithWatch(cfg, client.Options{})
 	}
 
 	return &Options{
-		DryRun:    dryRunStrategy,
-		Printer:   printer,
-		Template:  template,
-		Namespace: namespace,
-		NewClient: newClient,
-		IOStreams: f.IOStreams,
+		DryRun:                   dryRunStrategy,
+		Printer:                  printer,
+		Template:                 template,
+		Namespace:                namespace,
+		PrintBootstrapKubeconfig: printBootstrapKubeconfig,
+		BootstrapKubeconfigFile:  f.BootstrapKubeconfigFile,
+		PrintJoinCommand:         f.PrintJoinCommand,
+		JoinCommandTemplate:      joinCommandTemplate,
+		OneTime:                  f.OneTime,
+		Type:                     f.Type,
+		StartingCfg:              startingCfg,
+		NewClient:                newClient,
+		IOStreams:                f.IOStreams,
 	}, nil
 }
 
@@ -103,7 +168,23 @@ type Options struct {
 	Printer   printers.ResourcePrinter
 	Template  utilbootstraptoken.BootstrapToken
 	Namespace string
-	NewClient func() (client.Client, error)
+
+	// PrintBootstrapKubeconfig instructs to generate a bootstrap kubeconfig for the token from StartingCfg.
+	// If BootstrapKubeconfigFile is empty, the bootstrap kubeconfig is printed to Out.
+	PrintBootstrapKubeconfig bool
+	BootstrapKubeconfigFile  string
+
+	// PrintJoinCommand instructs to render JoinCommandTemplate for the token of Type to Out.
+	PrintJoinCommand    bool
+	JoinCommandTemplate string
+	Type                bootstraptoken.Type
+
+	// OneTime instructs to delete the token once the first certificate signing request made with it is submitted.
+	OneTime bool
+
+	StartingCfg *clientcmdapi.Config
+
+	NewClient func() (client.WithWatch, error)
 	genericclioptions.IOStreams
 }
 
@@ -113,6 +194,16 @@ func Command(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Comm
 	cmd := &cobra.Command{
 		Use:   "token",
 		Short: "Create a bootstrap token in a cluster.",
+		Long: `Create a bootstrap token in a cluster.
+
+With --one-time, the command keeps running after creating the token until the first certificate
+signing request made with the token is submitted and then deletes the token, so it can only be
+used for a single registration. The pool that consumed the token is reported. If the command is
+interrupted before, the token is not deleted and stays valid.
+
+With --pool-name, the token is scoped to a single pool by an extra group of the form
+<type-group>:<pool-name>. 'certificate approve' only approves certificate signing requests of
+scoped tokens for that pool; see 'generate bootstrap-rbac --pool-scoped' to disable auto-approval.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			opts, err := flags.ToOptions(cmd)
 			if err != nil {
@@ -136,23 +227,92 @@ func Run(ctx context.Context, opts Options) error {
 
 	secret := utilbootstraptoken.ToSecret(t)
 
-	patchOpts := []client.PatchOption{client.ForceOwnership, api.FieldOwner}
-	if opts.DryRun == cmdutil.DryRunServer {
-		patchOpts = append(patchOpts, client.DryRunAll)
-	}
-
+	var c client.WithWatch
 	if opts.DryRun != cmdutil.DryRunClient {
-		c, err := opts.NewClient()
+		c, err = opts.NewClient()
 		if err != nil {
 			return err
 		}
-		if err := c.Patch(ctx, secret, client.Apply, patchOpts...); err != nil {
+		if err := apply.Object(ctx, c, secret, opts.DryRun); err != nil {
 			return err
 		}
 	}
 
-	if err := opts.Printer.PrintObj(secret, opts.Out); err != nil {
+	printOut := opts.Out
+	if (opts.PrintBootstrapKubeconfig && opts.BootstrapKubeconfigFile == "") || opts.PrintJoinCommand {
+		printOut = opts.ErrOut
+	}
+	if err := opts.Printer.PrintObj(secret, printOut); err != nil {
 		return fmt.Errorf("error printing object: %w", err)
 	}
+
+	if opts.PrintBootstrapKubeconfig || opts.PrintJoinCommand {
+		if err := printBootstrapInfo(opts, t); err != nil {
+			return err
+		}
+	}
+
+	if opts.OneTime {
+		return waitAndRevoke(ctx, c, secret, t.ID, opts.ErrOut)
+	}
+	return nil
+}
+
+func printBootstrapInfo(opts Options, t *utilbootstraptoken.BootstrapToken) error {
+	apiCfg, err := kubeconfig.GenerateBootstrap(opts.StartingCfg, t)
+	if err != nil {
+		return err
+	}
+
+	if opts.PrintBootstrapKubeconfig {
+		apiCfgData, err := clientcmd.Write(*apiCfg)
+		if err != nil {
+			return err
+		}
+
+		if opts.BootstrapKubeconfigFile != "" {
+			if err := kubeconfig.WriteFile(opts.BootstrapKubeconfigFile, apiCfgData); err != nil {
+				return fmt.Errorf("error writing bootstrap kubeconfig: %w", err)
+			}
+		} else {
+			_, _ = opts.Out.Write(apiCfgData)
+		}
+	}
+
+	if opts.PrintJoinCommand {
+		data, err := joincommand.NewData(apiCfg, opts.Type, t)
+		if err != nil {
+			return err
+		}
+
+		if err := joincommand.Render(opts.Out, opts.JoinCommandTemplate, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// waitAndRevoke waits for the first certificate signing request made with the token of the given id
+// to be submitted, deletes the token secret and reports which pool consumed the token.
+func waitAndRevoke(ctx context.Context, c client.WithWatch, secret *corev1.Secret, id string, out io.Writer) error {
+	_, _ = fmt.Fprintf(out, "Waiting for the first certificate signing request made with bootstrap token %s\n", id)
+	csr, err := bootstrapcsr.WaitForFirst(ctx, c, id)
+	if err != nil {
+		_, _ = fmt.Fprintf(out, "Warning: bootstrap token %s was not deleted and is still valid, "+
+			"delete it with 'kubectl ironcore delete token %s'\n", id, id)
+		return fmt.Errorf("error waiting for bootstrap token %s to be used: %w", id, err)
+	}
+
+	if err := c.Delete(context.WithoutCancel(ctx), secret); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("error deleting bootstrap token %s: %w", id, err)
+	}
+
+	consumer := fmt.Sprintf("certificate signing request %s", csr.Name)
+	if req, err := bootstrapcsr.Validate(csr); err == nil {
+		consumer = fmt.Sprintf("%s pool %s via %s", req.Type, req.PoolName, consumer)
+	} else if certReq, err := bootstrapcsr.ParseCertificateRequest(csr); err == nil {
+		consumer = fmt.Sprintf("%s for %s", consumer, certReq.Subject.CommonName)
+	}
+	_, _ = fmt.Fprintf(out, "Bootstrap token %s was consumed by %s and deleted\n", id, consumer)
 	return nil
 }
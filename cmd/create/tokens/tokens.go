@@ -0,0 +1,281 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package tokens
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ironcore-dev/kubectl-ironcore/bootstraptoken"
+	"github.com/ironcore-dev/kubectl-ironcore/utils/apply"
+	utilbootstraptoken "github.com/ironcore-dev/kubectl-ironcore/utils/bootstraptoken"
+	"github.com/ironcore-dev/kubectl-ironcore/utils/kubeconfig"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type Flags struct {
+	Factory    cmdutil.Factory
+	Filename   string
+	OutputDir  string
+	PoolScoped bool
+	Expiry     bootstraptoken.ExpiryPolicy
+	PrintFlags *genericclioptions.PrintFlags
+	genericclioptions.IOStreams
+}
+
+func NewFlags(f cmdutil.Factory, streams genericclioptions.IOStreams) *Flags {
+	printFlags := genericclioptions.NewPrintFlags("created").
+		WithTypeSetter(scheme.Scheme)
+
+	return &Flags{
+		Factory:    f,
+		PrintFlags: printFlags,
+		IOStreams:  streams,
+	}
+}
+
+func (f *Flags) AddFlags(cmd *cobra.Command) {
+	cmdutil.AddDryRunFlag(cmd)
+	cmd.Flags().StringVarP(&f.Filename, "filename", "f", "", "Inventory file listing the tokens to create.")
+	cmd.Flags().StringVar(&f.OutputDir, "output-dir", ".", "Directory to write the bootstrap kubeconfigs of the tokens to.")
+	cmd.Flags().BoolVar(&f.PoolScoped, "pool-scoped", true, "Whether to scope each token to the pool of its entry.")
+	_ = cmd.MarkFlagRequired("filename")
+	f.Expiry.AddFlags(cmd)
+	f.PrintFlags.AddFlags(cmd)
+}
+
+func (f *Flags) ToOptions(cmd *cobra.Command) (*Options, error) {
+	entries, err := bootstraptoken.LoadInventoryFile(f.Filename)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("inventory file %s does not contain any entries", f.Filename)
+	}
+
+	if err := f.Expiry.Complete(cmd); err != nil {
+		return nil, err
+	}
+
+	dryRunStrategy, err := cmdutil.GetDryRunStrategy(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	cmdutil.PrintFlagsWithDryRunStrategy(f.PrintFlags, dryRunStrategy)
+	printer, err := f.PrintFlags.ToPrinter()
+	if err != nil {
+		return nil, err
+	}
+
+	rawCfg, err := f.Factory.ToRawKubeConfigLoader().RawConfig()
+	if err != nil {
+		return nil, err
+	}
+	if contextName, _ := cmd.Flags().GetString(clientcmd.FlagContext); contextName != "" {
+		rawCfg.CurrentContext = contextName
+	}
+
+	cfg, err := f.Factory.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	newClient := func() (client.Client, error) {
+		return client.New(cfg, client.Options{})
+	}
+
+	return &Options{
+		Entries:     entries,
+		OutputDir:   f.OutputDir,
+		PoolScoped:  f.PoolScoped,
+		Expiry:      f.Expiry,
+		DryRun:      dryRunStrategy,
+		Printer:     printer,
+		StartingCfg: &rawCfg,
+		NewClient:   newClient,
+		IOStreams:   f.IOStreams,
+	}, nil
+}
+
+type Options struct {
+	Entries []bootstraptoken.InventoryEntry
+
+	// OutputDir is the directory to write a bootstrap kubeconfig generated from StartingCfg per entry to.
+	OutputDir string
+	// PoolScoped instructs to scope each token to the pool of its entry (see bootstraptoken.PoolGroup).
+	PoolScoped bool
+	// Expiry is the policy the expiration of each token is checked against.
+	Expiry bootstraptoken.ExpiryPolicy
+
+	DryRun  cmdutil.DryRunStrategy
+	Printer printers.ResourcePrinter
+
+	StartingCfg *clientcmdapi.Config
+
+	NewClient func() (client.Client, error)
+	genericclioptions.IOStreams
+}
+
+func Command(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
+	flags := NewFlags(f, streams)
+
+	cmd := &cobra.Command{
+		Use:   "tokens -f <inventory-file>",
+		Short: "Create bootstrap tokens for a list of pools in a cluster.",
+		Long: `Create bootstrap tokens for a list of pools in a cluster.
+
+The inventory file lists the tokens to create:
+
+  - type: MachinePool
+    poolName: rack-1-node-1
+    ttl: 24h
+    description: Machine pool of rack 1, node 1.
+  - type: VolumePool
+    poolName: rack-1-storage-1
+    expires: 2030-01-31T12:00:00Z
+
+For each entry, a bootstrap kubeconfig is written to <output-dir>/<type>-<pool-name>.kubeconfig.
+Entries that fail are reported and do not prevent creating the tokens of the other entries.
+In dry run mode, no bootstrap kubeconfigs are written.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts, err := flags.ToOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			return Run(cmd.Context(), *opts)
+		},
+	}
+
+	flags.AddFlags(cmd)
+
+	return cmd
+}
+
+func Run(ctx context.Context, opts Options) error {
+	var c client.Client
+	if opts.DryRun != cmdutil.DryRunClient {
+		var err error
+		c, err = opts.NewClient()
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.DryRun == cmdutil.DryRunNone {
+		if err := os.MkdirAll(opts.OutputDir, 0700); err != nil {
+			return fmt.Errorf("error creating output directory: %w", err)
+		}
+	}
+
+	var (
+		seen      = sets.New[string]()
+		numFailed int
+	)
+	for _, entry := range opts.Entries {
+		filename := Filename(entry)
+		if seen.Has(filename) {
+			_, _ = fmt.Fprintf(opts.ErrOut, "Error creating token for %s: duplicate entry\n", entry)
+			numFailed++
+			continue
+		}
+		seen.Insert(filename)
+
+		if err := createToken(ctx, c, entry, filepath.Join(opts.OutputDir, filename), opts); err != nil {
+			_, _ = fmt.Fprintf(opts.ErrOut, "Error creating token for %s: %v\n", entry, err)
+			numFailed++
+		}
+	}
+	if numFailed > 0 {
+		return fmt.Errorf("%d of %d token(s) could not be created", numFailed, len(opts.Entries))
+	}
+	return nil
+}
+
+// Filename returns the name of the bootstrap kubeconfig file of the given entry.
+func Filename(entry bootstraptoken.InventoryEntry) string {
+	return fmt.Sprintf("%s-%s.kubeconfig", strings.ToLower(string(entry.Type)), entry.PoolName)
+}
+
+func createToken(ctx context.Context, c client.Client, entry bootstraptoken.InventoryEntry, filename string, opts Options) error {
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+
+	template := &utilbootstraptoken.BootstrapToken{Description: entry.Description}
+	switch {
+	case entry.TTL != nil:
+		template = template.WithTTL(entry.TTL.Duration)
+	case entry.Expires != nil:
+		template = template.WithExpires(entry.Expires.Time)
+	}
+	if err := opts.Expiry.Check(template.Expires, time.Now()); err != nil {
+		return err
+	}
+	if err := bootstraptoken.AddTypeFields(template, entry.Type); err != nil {
+		return err
+	}
+	if opts.PoolScoped {
+		if err := bootstraptoken.AddPoolGroup(template, entry.Type, entry.PoolName); err != nil {
+			return err
+		}
+	}
+
+	t, err := utilbootstraptoken.Generate(template)
+	if err != nil {
+		return fmt.Errorf("error generating token: %w", err)
+	}
+
+	apiCfg, err := kubeconfig.GenerateBootstrap(opts.StartingCfg, t)
+	if err != nil {
+		return err
+	}
+	apiCfgData, err := clientcmd.Write(*apiCfg)
+	if err != nil {
+		return err
+	}
+
+	// Write the bootstrap kubeconfig before creating the token so that no token is left behind
+	// if it cannot be written. It is only moved into place once the token was created.
+	var tmpFilename string
+	if opts.DryRun == cmdutil.DryRunNone {
+		tmpFilename, err = kubeconfig.WriteTempFile(filename, apiCfgData)
+		if err != nil {
+			return fmt.Errorf("error writing bootstrap kubeconfig: %w", err)
+		}
+		defer func() { _ = os.Remove(tmpFilename) }()
+	}
+
+	secret := utilbootstraptoken.ToSecret(t)
+	if c != nil {
+		if err := apply.Object(ctx, c, secret, opts.DryRun); err != nil {
+			return err
+		}
+	}
+
+	if tmpFilename != "" {
+		if err := os.Rename(tmpFilename, filename); err != nil {
+			return fmt.Errorf("error writing bootstrap kubeconfig: %w", err)
+		}
+	}
+
+	if err := opts.Printer.PrintObj(secret, opts.Out); err != nil {
+		return fmt.Errorf("error printing object: %w", err)
+	}
+	return nil
+}
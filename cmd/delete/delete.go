@@ -0,0 +1,24 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package delete
+
+import (
+	"github.com/ironcore-dev/kubectl-ironcore/cmd/delete/token"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+func Command(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete items from a cluster.",
+	}
+
+	cmd.AddCommand(
+		token.Command(f, streams),
+	)
+
+	return cmd
+}
@@ -0,0 +1,198 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package token
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ironcore-dev/kubectl-ironcore/bootstraptoken"
+	utilbootstraptoken "github.com/ironcore-dev/kubectl-ironcore/utils/bootstraptoken"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/cluster-bootstrap/token/util"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type Flags struct {
+	Factory    cmdutil.Factory
+	Expired    bool
+	Type       bootstraptoken.Type
+	PrintFlags *genericclioptions.PrintFlags
+	genericclioptions.IOStreams
+}
+
+func NewFlags(f cmdutil.Factory, streams genericclioptions.IOStreams) *Flags {
+	printFlags := genericclioptions.NewPrintFlags("deleted").
+		WithTypeSetter(scheme.Scheme)
+
+	return &Flags{
+		Factory:    f,
+		PrintFlags: printFlags,
+		IOStreams:  streams,
+	}
+}
+
+func (f *Flags) AddFlags(cmd *cobra.Command) {
+	cmdutil.AddDryRunFlag(cmd)
+	cmd.Flags().BoolVar(&f.Expired, "expired", false, "Delete all tokens that are expired.")
+	bootstraptoken.AddTypeFlag(cmd, &f.Type, "Delete all tokens of the given type.")
+	f.PrintFlags.AddFlags(cmd)
+}
+
+func (f *Flags) ToOptions(cmd *cobra.Command, args []string) (*Options, error) {
+	if len(args) == 0 && !f.Expired && f.Type == "" {
+		return nil, fmt.Errorf("must specify either token ids, --expired or --token-type")
+	}
+	if len(args) > 0 && (f.Expired || f.Type != "") {
+		return nil, fmt.Errorf("cannot specify token ids together with --expired or --token-type")
+	}
+	if f.Type != "" && !bootstraptoken.AvailableTypes.Has(f.Type) {
+		return nil, fmt.Errorf("unknown type %q", f.Type)
+	}
+
+	ids := make([]string, 0, len(args))
+	for _, arg := range args {
+		id, err := utilbootstraptoken.ParseIDOrToken(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	dryRunStrategy, err := cmdutil.GetDryRunStrategy(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	cmdutil.PrintFlagsWithDryRunStrategy(f.PrintFlags, dryRunStrategy)
+	printer, err := f.PrintFlags.ToPrinter()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := f.Factory.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	newClient := func() (client.Client, error) {
+		return client.New(cfg, client.Options{})
+	}
+
+	return &Options{
+		IDs:       ids,
+		Expired:   f.Expired,
+		Type:      f.Type,
+		DryRun:    dryRunStrategy,
+		Printer:   printer,
+		NewClient: newClient,
+		IOStreams: f.IOStreams,
+	}, nil
+}
+
+type Options struct {
+	IDs       []string
+	Expired   bool
+	Type      bootstraptoken.Type
+	DryRun    cmdutil.DryRunStrategy
+	Printer   printers.ResourcePrinter
+	NewClient func() (client.Client, error)
+	genericclioptions.IOStreams
+}
+
+func Command(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
+	flags := NewFlags(f, streams)
+
+	cmd := &cobra.Command{
+		Use:   "token [<token-id> | <token>]...",
+		Short: "Delete bootstrap tokens from a cluster.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts, err := flags.ToOptions(cmd, args)
+			if err != nil {
+				return err
+			}
+
+			return Run(cmd.Context(), *opts)
+		},
+	}
+
+	flags.AddFlags(cmd)
+
+	return cmd
+}
+
+func Run(ctx context.Context, opts Options) error {
+	c, err := opts.NewClient()
+	if err != nil {
+		return err
+	}
+
+	ids := opts.IDs
+	if opts.Expired || opts.Type != "" {
+		ids, err = selectIDs(ctx, c, opts, time.Now())
+		if err != nil {
+			return err
+		}
+	}
+
+	var deleteOpts []client.DeleteOption
+	if opts.DryRun == cmdutil.DryRunServer {
+		deleteOpts = append(deleteOpts, client.DryRunAll)
+	}
+
+	for _, id := range ids {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: metav1.NamespaceSystem,
+				Name:      util.BootstrapTokenSecretName(id),
+			},
+		}
+
+		if opts.DryRun != cmdutil.DryRunClient {
+			if err := c.Delete(ctx, secret, deleteOpts...); err != nil {
+				return fmt.Errorf("error deleting bootstrap token %s: %w", id, err)
+			}
+		}
+
+		if err := opts.Printer.PrintObj(secret, opts.Out); err != nil {
+			return fmt.Errorf("error printing object: %w", err)
+		}
+	}
+	return nil
+}
+
+func selectIDs(ctx context.Context, c client.Reader, opts Options, now time.Time) ([]string, error) {
+	secrets, err := utilbootstraptoken.ListSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for i := range secrets {
+		secret := &secrets[i]
+		token, err := utilbootstraptoken.FromSecret(secret)
+		if err != nil {
+			_, _ = fmt.Fprintf(opts.ErrOut, "Skipping secret %s/%s: %v\n", secret.Namespace, secret.Name, err)
+			continue
+		}
+
+		if opts.Expired && (token.Expires == nil || token.Expires.After(now)) {
+			continue
+		}
+		if opts.Type != "" && !bootstraptoken.HasType(token, opts.Type) {
+			continue
+		}
+
+		ids = append(ids, token.ID)
+	}
+	return ids, nil
+}
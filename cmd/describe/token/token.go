@@ -0,0 +1,168 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package token
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ironcore-dev/kubectl-ironcore/bootstraptoken"
+	utilbootstraptoken "github.com/ironcore-dev/kubectl-ironcore/utils/bootstraptoken"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/cluster-bootstrap/token/util"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const none = "<none>"
+
+type Flags struct {
+	Factory cmdutil.Factory
+	genericclioptions.IOStreams
+}
+
+func NewFlags(f cmdutil.Factory, streams genericclioptions.IOStreams) *Flags {
+	return &Flags{
+		Factory:   f,
+		IOStreams: streams,
+	}
+}
+
+func (f *Flags) ToOptions(args []string) (*Options, error) {
+	ids := make([]string, 0, len(args))
+	for _, arg := range args {
+		id, err := utilbootstraptoken.ParseIDOrToken(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	cfg, err := f.Factory.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	newClient := func() (client.Client, error) {
+		return client.New(cfg, client.Options{})
+	}
+
+	return &Options{
+		IDs:       ids,
+		NewClient: newClient,
+		IOStreams: f.IOStreams,
+	}, nil
+}
+
+type Options struct {
+	IDs       []string
+	NewClient func() (client.Client, error)
+	genericclioptions.IOStreams
+}
+
+func Command(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
+	flags := NewFlags(f, streams)
+
+	cmd := &cobra.Command{
+		Use:   "token (<token-id> | <token>)...",
+		Short: "Show details of bootstrap tokens in a cluster.",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts, err := flags.ToOptions(args)
+			if err != nil {
+				return err
+			}
+
+			return Run(cmd.Context(), *opts)
+		},
+	}
+
+	return cmd
+}
+
+func Run(ctx context.Context, opts Options) error {
+	c, err := opts.NewClient()
+	if err != nil {
+		return err
+	}
+
+	for i, id := range opts.IDs {
+		secret := &corev1.Secret{}
+		secretKey := client.ObjectKey{Namespace: metav1.NamespaceSystem, Name: util.BootstrapTokenSecretName(id)}
+		if err := c.Get(ctx, secretKey, secret); err != nil {
+			return fmt.Errorf("error getting bootstrap token %s: %w", id, err)
+		}
+
+		token, err := utilbootstraptoken.FromSecret(secret)
+		if err != nil {
+			return fmt.Errorf("error decoding bootstrap token from secret: %w", err)
+		}
+
+		if i > 0 {
+			_, _ = fmt.Fprintln(opts.Out)
+		}
+		if err := describe(opts.Out, secret, token, time.Now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func describe(out io.Writer, secret *corev1.Secret, token *utilbootstraptoken.BootstrapToken, now time.Time) error {
+	w := printers.GetNewTabWriter(out)
+
+	var types, poolNames []string
+	for _, typ := range bootstraptoken.TypesOf(token) {
+		types = append(types, string(typ))
+		poolNames = append(poolNames, bootstraptoken.PoolNamesOfGroups(typ, token.Groups)...)
+	}
+
+	_, _ = fmt.Fprintf(w, "Name:\t%s\n", secret.Name)
+	_, _ = fmt.Fprintf(w, "Namespace:\t%s\n", secret.Namespace)
+	_, _ = fmt.Fprintf(w, "ID:\t%s\n", token.ID)
+	_, _ = fmt.Fprintf(w, "Types:\t%s\n", joinOrNone(types))
+	_, _ = fmt.Fprintf(w, "Pools:\t%s\n", joinOrNone(poolNames))
+	_, _ = fmt.Fprintf(w, "Description:\t%s\n", stringOrNone(token.Description))
+	_, _ = fmt.Fprintf(w, "Created:\t%s\n", secret.CreationTimestamp.UTC().Format(time.RFC3339))
+	_, _ = fmt.Fprintf(w, "Expires:\t%s\n", formatExpires(token.Expires, now))
+	_, _ = fmt.Fprintf(w, "Usages:\t%s\n", joinOrNone(token.Usages))
+	_, _ = fmt.Fprintf(w, "Groups:\t%s\n", joinOrNone(token.Groups))
+
+	return w.Flush()
+}
+
+func formatExpires(expires *time.Time, now time.Time) string {
+	if expires == nil {
+		return "<never>"
+	}
+
+	formatted := expires.UTC().Format(time.RFC3339)
+	if !expires.After(now) {
+		return fmt.Sprintf("%s (expired %s ago)", formatted, duration.HumanDuration(now.Sub(*expires)))
+	}
+	return fmt.Sprintf("%s (in %s)", formatted, duration.HumanDuration(expires.Sub(now)))
+}
+
+func joinOrNone(items []string) string {
+	if len(items) == 0 {
+		return none
+	}
+	return strings.Join(items, ", ")
+}
+
+func stringOrNone(s string) string {
+	if s == "" {
+		return none
+	}
+	return s
+}
@@ -0,0 +1,139 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package exec
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+)
+
+const (
+	// DefaultEscapeChar is the default character introducing an escape sequence.
+	DefaultEscapeChar = '~'
+	// NoEscapeChar is the --escape-char value disabling escape sequences.
+	NoEscapeChar = "none"
+)
+
+// breakSequence is sent to the machine console on a break escape sequence.
+// The streaming protocols have no out-of-band break signal, so Ctrl-C is sent instead.
+var breakSequence = []byte{0x03}
+
+// ParseEscapeChar parses the given --escape-char value. NoEscapeChar results in 0.
+func ParseEscapeChar(s string) (byte, error) {
+	if s == NoEscapeChar {
+		return 0, nil
+	}
+	if len(s) != 1 || s[0] == '\r' || s[0] == '\n' {
+		return 0, fmt.Errorf("invalid escape character %q, must be a single character or %q", s, NoEscapeChar)
+	}
+	return s[0], nil
+}
+
+// escapeReader interprets escape sequences in the input read from r. Escape sequences are only
+// recognized directly after a newline (or at the very beginning), similar to OpenSSH:
+//
+//	<escape>.         detach
+//	<escape>B         send a break
+//	<escape>?         print the supported escape sequences to help
+//	<escape><escape>  send the escape character itself
+type escapeReader struct {
+	r          io.Reader
+	escapeChar byte
+	help       io.Writer
+	detach     func()
+
+	buf         []byte
+	atLineStart bool
+	escaped     bool
+	detached    atomic.Bool
+}
+
+func newEscapeReader(r io.Reader, escapeChar byte, help io.Writer, detach func()) *escapeReader {
+	return &escapeReader{
+		r:           r,
+		escapeChar:  escapeChar,
+		help:        help,
+		detach:      detach,
+		atLineStart: true,
+	}
+}
+
+func (e *escapeReader) Read(p []byte) (int, error) {
+	for len(e.buf) == 0 {
+		if e.detached.Load() {
+			return 0, io.EOF
+		}
+
+		in := make([]byte, len(p))
+		n, err := e.r.Read(in)
+		e.process(in[:n])
+		if err != nil {
+			if len(e.buf) > 0 {
+				break
+			}
+			return 0, err
+		}
+	}
+
+	n := copy(p, e.buf)
+	e.buf = e.buf[n:]
+	return n, nil
+}
+
+func (e *escapeReader) process(in []byte) {
+	for _, b := range in {
+		if e.detached.Load() {
+			return
+		}
+
+		if e.escaped {
+			e.escaped = false
+			switch b {
+			case '.':
+				e.detached.Store(true)
+				e.detach()
+				return
+			case 'B':
+				e.buf = append(e.buf, breakSequence...)
+			case '?':
+				e.printHelp()
+			case e.escapeChar:
+				e.buf = append(e.buf, b)
+			default:
+				e.buf = append(e.buf, e.escapeChar, b)
+			}
+			e.atLineStart = isNewline(b)
+			continue
+		}
+
+		if e.atLineStart && b == e.escapeChar {
+			e.escaped = true
+			continue
+		}
+
+		e.buf = append(e.buf, b)
+		e.atLineStart = isNewline(b)
+	}
+}
+
+// Detached reports whether the detach escape sequence was read.
+func (e *escapeReader) Detached() bool {
+	return e.detached.Load()
+}
+
+func (e *escapeReader) printHelp() {
+	c := string(e.escapeChar)
+	// The terminal is in raw mode, so lines have to be terminated with \r\n.
+	_, _ = fmt.Fprintf(e.help, "\r\nSupported escape sequences:\r\n"+
+		" %[1]s.  - detach from the machine console\r\n"+
+		" %[1]sB  - send a break (Ctrl-C) to the machine\r\n"+
+		" %[1]s?  - this message\r\n"+
+		" %[1]s%[1]s  - send the escape character by typing it twice\r\n"+
+		"(Note that escapes are only recognized immediately after newline.)\r\n", c)
+}
+
+func isNewline(b byte) bool {
+	return b == '\r' || b == '\n'
+}
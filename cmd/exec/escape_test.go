@@ -0,0 +1,196 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package exec
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// chunkedReader returns one of its chunks per read.
+type chunkedReader struct {
+	chunks []string
+}
+
+func (r *chunkedReader) Read(p []byte) (int, error) {
+	if len(r.chunks) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.chunks[0])
+	if r.chunks[0] = r.chunks[0][n:]; r.chunks[0] == "" {
+		r.chunks = r.chunks[1:]
+	}
+	return n, nil
+}
+
+func TestEscapeReader(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		escapeChar     byte
+		chunks         []string
+		expectedOutput string
+		expectDetached bool
+		expectHelp     bool
+	}{
+		{
+			name:           "plain input",
+			chunks:         []string{"ls -l\r", "exit\r"},
+			expectedOutput: "ls -l\rexit\r",
+		},
+		{
+			name:           "detach at beginning",
+			chunks:         []string{"~.ignored"},
+			expectDetached: true,
+		},
+		{
+			name:           "detach after newline",
+			chunks:         []string{"ls\r~.ignored", "ignored"},
+			expectedOutput: "ls\r",
+			expectDetached: true,
+		},
+		{
+			name:           "detach after line feed",
+			chunks:         []string{"ls\n~."},
+			expectedOutput: "ls\n",
+			expectDetached: true,
+		},
+		{
+			name:           "escape char not after newline",
+			chunks:         []string{"echo a~.b\r"},
+			expectedOutput: "echo a~.b\r",
+		},
+		{
+			name:           "break",
+			chunks:         []string{"~B"},
+			expectedOutput: "\x03",
+		},
+		{
+			name:           "escape char only at line start after break",
+			chunks:         []string{"~B~."},
+			expectedOutput: "\x03~.",
+		},
+		{
+			name:           "literal escape char",
+			chunks:         []string{"~~"},
+			expectedOutput: "~",
+		},
+		{
+			name:           "literal escape char does not start a sequence",
+			chunks:         []string{"~~."},
+			expectedOutput: "~.",
+		},
+		{
+			name:       "help",
+			chunks:     []string{"~?"},
+			expectHelp: true,
+		},
+		{
+			name:           "unknown sequence",
+			chunks:         []string{"~x"},
+			expectedOutput: "~x",
+		},
+		{
+			name:           "detach split across reads",
+			chunks:         []string{"ls\r~", "."},
+			expectedOutput: "ls\r",
+			expectDetached: true,
+		},
+		{
+			name:           "break split across three reads",
+			chunks:         []string{"\r", "~", "B"},
+			expectedOutput: "\r\x03",
+		},
+		{
+			name:           "custom escape char",
+			escapeChar:     '^',
+			chunks:         []string{"~.\r^B\r^."},
+			expectedOutput: "~.\r\x03\r",
+			expectDetached: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			escapeChar := tc.escapeChar
+			if escapeChar == 0 {
+				escapeChar = DefaultEscapeChar
+			}
+
+			var (
+				help     = &bytes.Buffer{}
+				detaches int
+			)
+			r := newEscapeReader(&chunkedReader{chunks: tc.chunks}, escapeChar, help, func() { detaches++ })
+
+			output, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(output) != tc.expectedOutput {
+				t.Errorf("expected output %q, got %q", tc.expectedOutput, output)
+			}
+			if r.Detached() != tc.expectDetached {
+				t.Errorf("expected detached to be %t", tc.expectDetached)
+			}
+			if expected := map[bool]int{true: 1}[tc.expectDetached]; detaches != expected {
+				t.Errorf("expected detach to be called %d time(s), got %d", expected, detaches)
+			}
+			if printed := strings.Contains(help.String(), "Supported escape sequences"); printed != tc.expectHelp {
+				t.Errorf("expected help to be printed to be %t, got %q", tc.expectHelp, help.String())
+			}
+		})
+	}
+}
+
+func TestEscapeReaderSmallBuffer(t *testing.T) {
+	r := newEscapeReader(&chunkedReader{chunks: []string{"~B~B\r"}}, DefaultEscapeChar, io.Discard, func() {})
+
+	var output []byte
+	buf := make([]byte, 1)
+	for {
+		n, err := r.Read(buf)
+		output = append(output, buf[:n]...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if string(output) != "\x03~B\r" {
+		t.Errorf("expected output %q, got %q", "\x03~B\r", output)
+	}
+}
+
+func TestParseEscapeChar(t *testing.T) {
+	for _, tc := range []struct {
+		value       string
+		expected    byte
+		expectError bool
+	}{
+		{value: "~", expected: '~'},
+		{value: "^", expected: '^'},
+		{value: NoEscapeChar, expected: 0},
+		{value: "", expectError: true},
+		{value: "~~", expectError: true},
+		{value: "\r", expectError: true},
+		{value: "\n", expectError: true},
+	} {
+		t.Run(tc.value, func(t *testing.T) {
+			actual, err := ParseEscapeChar(tc.value)
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected an error, got %q", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -6,91 +6,320 @@ package exec
 import (
 	"context"
 	"fmt"
-	"net/http"
-	"os"
+	"io"
+	"net/url"
+	"slices"
+	"time"
 
 	computev1alpha1 "github.com/ironcore-dev/ironcore/api/compute/v1alpha1"
 	ironcoreclientgo "github.com/ironcore-dev/ironcore/client-go/ironcore"
 	ironcoreclientgoscheme "github.com/ironcore-dev/ironcore/client-go/ironcore/scheme"
+	"github.com/ironcore-dev/kubectl-ironcore/asciicast"
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/remotecommand"
 	"k8s.io/kubectl/pkg/util/term"
 )
 
-func Command(restClientGetter genericclioptions.RESTClientGetter) *cobra.Command {
-	var insecureSkipTLSVerifyBackend bool
+type Flags struct {
+	RESTClientGetter             genericclioptions.RESTClientGetter
+	InsecureSkipTLSVerifyBackend bool
+	Stdin                        bool
+	TTY                          bool
+	Transport                    string
+	EscapeChar                   string
+	Record                       string
+	RecordInput                  bool
+	Reconnect                    bool
+	ReconnectTimeout             time.Duration
+	genericclioptions.IOStreams
+}
+
+func NewFlags(restClientGetter genericclioptions.RESTClientGetter, streams genericclioptions.IOStreams) *Flags {
+	return &Flags{
+		RESTClientGetter: restClientGetter,
+		IOStreams:        streams,
+	}
+}
+
+func (f *Flags) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&f.InsecureSkipTLSVerifyBackend, "insecure-skip-tls-verify-backend", false, "Whether to skip tls verification on the machinepoollet exec backend.")
+	cmd.Flags().BoolVarP(&f.Stdin, "stdin", "i", false, "Pass stdin to the machine console.")
+	cmd.Flags().BoolVarP(&f.TTY, "tty", "t", false, "Stdin is a TTY.")
+	cmd.Flags().StringVar(&f.Transport, "transport", TransportAuto, fmt.Sprintf("Transport to stream with. One of: %v. %s uses WebSocket and falls back to SPDY.", Transports, TransportAuto))
+	_ = cmd.RegisterFlagCompletionFunc("transport", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return Transports, cobra.ShellCompDirectiveNoFileComp
+	})
+	cmd.Flags().StringVar(&f.EscapeChar, "escape-char", string(DefaultEscapeChar), fmt.Sprintf("Escape character for escape sequences in TTY mode, %q disables them.", NoEscapeChar))
+	cmd.Flags().StringVar(&f.Record, "record", "", "File to record the session to in asciicast v2 format. Replay it with 'kubectl ironcore console replay'.")
+	cmd.Flags().BoolVar(&f.RecordInput, "record-input", false, "Whether to also record the input sent to the machine console. Requires --record.")
+	cmd.Flags().BoolVar(&f.Reconnect, "reconnect", false, "Whether to reconnect if the connection to the machine console is lost.")
+	cmd.Flags().DurationVar(&f.ReconnectTimeout, "reconnect-timeout", 5*time.Minute, "Time after which to give up reconnecting. Requires --reconnect.")
+}
+
+func (f *Flags) ToOptions(cmd *cobra.Command, args []string) (*Options, error) {
+	cfg, err := f.RESTClientGetter.ToRESTConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error getting rest config: %w", err)
+	}
+
+	namespace, _, err := f.RESTClientGetter.ToRawKubeConfigLoader().Namespace()
+	if err != nil {
+		return nil, fmt.Errorf("error determining target namespace: %w", err)
+	}
+
+	if !slices.Contains(Transports, f.Transport) {
+		return nil, fmt.Errorf("unknown transport %q, must be one of %v", f.Transport, Transports)
+	}
+
+	if f.RecordInput && f.Record == "" {
+		return nil, fmt.Errorf("--record-input requires --record")
+	}
+
+	if f.ReconnectTimeout <= 0 {
+		return nil, fmt.Errorf("reconnect timeout has to be positive")
+	}
+
+	escapeChar, err := ParseEscapeChar(f.EscapeChar)
+	if err != nil {
+		return nil, err
+	}
+
+	stdin, tty, err := f.stdinAndTTY(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	newExecutor := func(u *url.URL) (remotecommand.Executor, error) {
+		return NewExecutor(cfg, f.Transport, u)
+	}
+
+	return &Options{
+		Name:                         args[0],
+		Namespace:                    namespace,
+		InsecureSkipTLSVerifyBackend: f.InsecureSkipTLSVerifyBackend,
+		Stdin:                        stdin,
+		TTY:                          tty,
+		EscapeChar:                   escapeChar,
+		Record:                       f.Record,
+		RecordInput:                  f.RecordInput,
+		Reconnect:                    f.Reconnect,
+		ReconnectTimeout:             f.ReconnectTimeout,
+		RESTConfig:                   cfg,
+		NewExecutor:                  newExecutor,
+		IOStreams:                    f.IOStreams,
+	}, nil
+}
+
+// stdinAndTTY determines whether to pass stdin and whether to use a TTY. If neither --stdin nor --tty
+// is specified, stdin is passed and a TTY is used if both stdin and stdout are terminals.
+func (f *Flags) stdinAndTTY(cmd *cobra.Command) (stdin, tty bool, err error) {
+	t := term.TTY{In: f.In, Out: f.Out}
+	if !cmd.Flags().Changed("stdin") && !cmd.Flags().Changed("tty") {
+		return true, t.IsTerminalIn() && t.IsTerminalOut(), nil
+	}
+
+	if f.TTY && !f.Stdin {
+		return false, false, fmt.Errorf("cannot use a TTY without --stdin")
+	}
+	if f.TTY && !t.IsTerminalIn() {
+		_, _ = fmt.Fprintln(f.ErrOut, "Unable to use a TTY - input is not a terminal or the right kind of file")
+		return f.Stdin, false, nil
+	}
+	return f.Stdin, f.TTY, nil
+}
+
+type Options struct {
+	Name                         string
+	Namespace                    string
+	InsecureSkipTLSVerifyBackend bool
+
+	// Stdin instructs to pass In to the machine console.
+	Stdin bool
+	// TTY instructs to put In into raw mode and to forward terminal size changes.
+	TTY bool
+	// EscapeChar is the character introducing escape sequences in TTY mode. Zero disables escape sequences.
+	EscapeChar byte
+
+	// Record is the file to record the session to in asciicast v2 format. Empty disables recording.
+	Record string
+	// RecordInput instructs to also record the input sent to the machine console.
+	RecordInput bool
+
+	// Reconnect instructs to re-establish the session if the connection is lost.
+	Reconnect bool
+	// ReconnectTimeout is the time after the connection was lost to give up reconnecting.
+	ReconnectTimeout time.Duration
+
+	RESTConfig  *rest.Config
+	NewExecutor func(u *url.URL) (remotecommand.Executor, error)
+	genericclioptions.IOStreams
+}
+
+func Command(restClientGetter genericclioptions.RESTClientGetter, streams genericclioptions.IOStreams) *cobra.Command {
+	flags := NewFlags(restClientGetter, streams)
 
 	cmd := &cobra.Command{
 		Use:   "exec <machine-name>",
 		Short: "Exec onto running entities in the cluster.",
-		Args:  cobra.ExactArgs(1),
+		Long: `Exec onto running entities in the cluster.
+
+If neither --stdin nor --tty is specified, stdin is passed to the machine console and a TTY is
+used if both stdin and stdout are terminals. This allows scripting the console, e.g.
+
+  echo reboot | kubectl ironcore exec my-machine
+
+By default, the console is streamed via WebSocket, falling back to SPDY if the server does not
+support WebSocket. Use --transport to force a transport.
+
+In TTY mode, the following escape sequences are recognized after a newline:
+
+  ~.  Detach from the machine console.
+  ~B  Send a break (Ctrl-C) to the machine.
+  ~?  Print the supported escape sequences.
+  ~~  Send a literal ~.
+
+The escape character can be changed with --escape-char or disabled with --escape-char=none.
+
+With --record, the session output (and with --record-input also its input) is recorded in
+asciicast v2 format. Recordings can be replayed with 'kubectl ironcore console replay'.
+
+With --reconnect, the session is re-established with backoff if the connection to the machine
+console is lost, e.g. because the machinepoollet or the API server restarts. Reconnecting is
+given up after --reconnect-timeout.`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctx := cmd.Context()
-			name := args[0]
-			return Run(ctx, restClientGetter, name, insecureSkipTLSVerifyBackend)
+			opts, err := flags.ToOptions(cmd, args)
+			if err != nil {
+				return err
+			}
+
+			return Run(cmd.Context(), *opts)
 		},
 	}
 
-	cmd.Flags().BoolVar(&insecureSkipTLSVerifyBackend, "insecure-skip-tls-verify-backend", insecureSkipTLSVerifyBackend, "Whether to skip tls verification on the machinepoollet exec backend.")
+	flags.AddFlags(cmd)
 
 	return cmd
 }
 
-func Run(ctx context.Context, restClientGetter genericclioptions.RESTClientGetter, name string, insecureSkipVerifyTLSBackend bool) error {
-	cfg, err := restClientGetter.ToRESTConfig()
-	if err != nil {
-		return fmt.Errorf("error getting rest config: %w", err)
-	}
-
-	namespace, _, err := restClientGetter.ToRawKubeConfigLoader().Namespace()
-	if err != nil {
-		return fmt.Errorf("error determining target namespace: %w", err)
-	}
-
-	ironcoreClientset, err := ironcoreclientgo.NewForConfig(cfg)
+func Run(ctx context.Context, opts Options) error {
+	ironcoreClientset, err := ironcoreclientgo.NewForConfig(opts.RESTConfig)
 	if err != nil {
 		return err
 	}
 
 	req := ironcoreClientset.ComputeV1alpha1().RESTClient().
 		Post().
-		Namespace(namespace).
+		Namespace(opts.Namespace).
 		Resource("machines").
-		Name(name).
+		Name(opts.Name).
 		SubResource("exec").
-		VersionedParams(&computev1alpha1.MachineExecOptions{InsecureSkipTLSVerifyBackend: insecureSkipVerifyTLSBackend}, ironcoreclientgoscheme.ParameterCodec)
+		VersionedParams(&computev1alpha1.MachineExecOptions{InsecureSkipTLSVerifyBackend: opts.InsecureSkipTLSVerifyBackend}, ironcoreclientgoscheme.ParameterCodec)
+
+	exec, err := opts.NewExecutor(req.URL())
+	if err != nil {
+		return err
+	}
 
-	var sizeQueue remotecommand.TerminalSizeQueue
 	tty := term.TTY{
-		In:     os.Stdin,
-		Out:    os.Stdout,
-		Raw:    true,
-		TryDev: true,
+		Out: opts.Out,
+	}
+	if opts.Stdin {
+		tty.In = opts.In
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		stdin       = tty.In
+		stdout      = tty.Out
+		size        *remotecommand.TerminalSize
+		sizePlusOne *remotecommand.TerminalSize
+		sizeQueue   remotecommand.TerminalSizeQueue
+		escape      *escapeReader
+	)
+	if opts.TTY {
+		tty.Raw = true
+		tty.TryDev = true
+
+		if size = tty.GetSize(); size != nil {
+			// fake resizing +1 and then back to normal so that attach-detach-reattach will result in the
+			// screen being redrawn
+			sizePlusOne = &remotecommand.TerminalSize{Width: size.Width + 1, Height: size.Height + 1}
+
+			// this call spawns a goroutine to monitor/update the terminal size
+			sizeQueue = tty.MonitorSize(sizePlusOne, size)
+		}
+
+		if opts.EscapeChar != 0 {
+			escape = newEscapeReader(tty.In, opts.EscapeChar, opts.ErrOut, cancel)
+			stdin = escape
+			_, _ = fmt.Fprintf(opts.ErrOut, "Escape character is %[1]c, type %[1]c? after a newline for help.\n", opts.EscapeChar)
+		}
+
+		_, _ = fmt.Fprintln(opts.ErrOut, "If you don't see a command prompt, try pressing enter.")
 	}
-	if size := tty.GetSize(); size != nil {
-		// fake resizing +1 and then back to normal so that attach-detach-reattach will result in the
-		// screen being redrawn
-		sizePlusOne := *size
-		sizePlusOne.Width++
-		sizePlusOne.Height++
 
-		// this call spawns a goroutine to monitor/update the terminal size
-		sizeQueue = tty.MonitorSize(&sizePlusOne, size)
+	if opts.Record != "" {
+		header := asciicast.Header{
+			Command: fmt.Sprintf("kubectl ironcore exec %s", opts.Name),
+			Title:   fmt.Sprintf("%s/%s", opts.Namespace, opts.Name),
+		}
+		if size != nil {
+			header.Width, header.Height = int(size.Width), int(size.Height)
+		}
+
+		rec, f, err := createRecording(opts.Record, header)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = rec.Flush()
+			_ = f.Close()
+		}()
+
+		stdout = io.MultiWriter(stdout, rec.EventWriter(asciicast.EventTypeOutput))
+		if opts.RecordInput && stdin != nil {
+			stdin = io.TeeReader(stdin, rec.EventWriter(asciicast.EventTypeInput))
+		}
+		if sizeQueue != nil {
+			sizeQueue = &recordingSizeQueue{
+				TerminalSizeQueue: sizeQueue,
+				rec:               rec,
+				last:              *size,
+				synthetic:         sizePlusOne,
+			}
+		}
 	}
 
-	exec, err := remotecommand.NewSPDYExecutor(cfg, http.MethodPost, req.URL())
-	if err != nil {
-		return err
+	// The machine console only has a single output stream, so no stderr is requested.
+	streamOpts := remotecommand.StreamOptions{
+		Stdin:             stdin,
+		Stdout:            stdout,
+		Tty:               opts.TTY,
+		TerminalSizeQueue: sizeQueue,
 	}
+	err = tty.Safe(func() error {
+		if !opts.Reconnect {
+			return exec.StreamWithContext(ctx, streamOpts)
+		}
 
-	_, _ = fmt.Fprintln(os.Stderr, "If you don't see a command prompt, try pressing enter.")
-	return tty.Safe(func() error {
-		return exec.StreamWithContext(ctx, remotecommand.StreamOptions{
-			Stdin:             tty.In,
-			Stdout:            tty.Out,
-			Tty:               true,
-			TerminalSizeQueue: sizeQueue,
-		})
+		// In raw mode, lines have to be terminated with \r\n.
+		lineEnd := "\n"
+		if opts.TTY {
+			lineEnd = "\r\n"
+		}
+		status := func(format string, args ...any) {
+			_, _ = fmt.Fprintf(opts.ErrOut, "\r%s: "+format+lineEnd, append([]any{opts.Name}, args...)...)
+		}
+		return streamWithReconnect(ctx, exec, streamOpts, opts.ReconnectTimeout, status)
 	})
+	if escape != nil && escape.Detached() {
+		_, _ = fmt.Fprintf(opts.ErrOut, "Detached from machine %s.\n", opts.Name)
+		return nil
+	}
+	return err
 }
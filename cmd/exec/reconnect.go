@@ -0,0 +1,319 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// ReconnectBackoff is the backoff between attempts to reconnect to a machine console.
+var ReconnectBackoff = wait.Backoff{
+	Duration: 500 * time.Millisecond,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    math.MaxInt32,
+	Cap:      10 * time.Second,
+}
+
+// streamWithReconnect streams a session with the given options, re-establishing it whenever it ends with a
+// retryable error. Reconnecting is given up if no session could be established within timeout after the
+// connection was lost. status is called with a status line for each reconnect.
+func streamWithReconnect(
+	ctx context.Context,
+	exec remotecommand.Executor,
+	opts remotecommand.StreamOptions,
+	timeout time.Duration,
+	status func(format string, args ...any),
+) error {
+	var (
+		input *inputPump
+		sizes *sizePump
+	)
+	if opts.Stdin != nil {
+		input = newInputPump(opts.Stdin)
+	}
+	if opts.TerminalSizeQueue != nil {
+		sizes = newSizePump(opts.TerminalSizeQueue)
+	}
+
+	var (
+		backoff   = ReconnectBackoff
+		lostAt    time.Time
+		attempt   int
+		reconnect bool
+	)
+	for {
+		var (
+			done        = make(chan struct{})
+			reconnected = reconnect
+			established atomic.Bool
+			connected   sync.Once
+			sessionOpts = opts
+		)
+		// The executors only start reading input and terminal sizes once the stream is connected,
+		// output marks sessions without either as established.
+		onConnect := func() {
+			connected.Do(func() {
+				established.Store(true)
+				if reconnected {
+					status("Reconnected.")
+				}
+			})
+		}
+		sessionOpts.Stdout = &firstWriteWriter{w: opts.Stdout, onFirstWrite: onConnect}
+		if input != nil {
+			sessionOpts.Stdin = &sessionReader{pump: input, done: done, onRead: onConnect}
+		}
+		if sizes != nil {
+			sessionOpts.TerminalSizeQueue = sizes.sessionQueue(done, reconnected, onConnect)
+		}
+
+		err := exec.StreamWithContext(ctx, sessionOpts)
+		// End the session so that goroutines of the executor still reading from it return.
+		close(done)
+		if err == nil || ctx.Err() != nil || !isRetryable(err) {
+			return err
+		}
+
+		now := time.Now()
+		if established.Load() || lostAt.IsZero() {
+			lostAt = now
+			backoff = ReconnectBackoff
+			attempt = 0
+		}
+		if now.Sub(lostAt) >= timeout {
+			return fmt.Errorf("could not reconnect within %s: %w", timeout, err)
+		}
+
+		delay := backoff.Step()
+		status("Connection lost: %v. Reconnecting in %s...", err, delay.Round(time.Millisecond))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+
+		attempt++
+		reconnect = true
+		status("Reconnecting (attempt %d)...", attempt)
+	}
+}
+
+// isRetryable reports whether reconnecting after the given error may succeed.
+// Only network and stream errors as well as server errors are retried.
+func isRetryable(err error) bool {
+	var netErr net.Error
+	switch {
+	case errors.As(err, &netErr),
+		errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		httpstream.IsUpgradeFailure(err),
+		apierrors.IsInternalError(err),
+		apierrors.IsServiceUnavailable(err),
+		apierrors.IsServerTimeout(err),
+		apierrors.IsTimeout(err),
+		apierrors.IsTooManyRequests(err):
+		return true
+	}
+
+	var status apierrors.APIStatus
+	return errors.As(err, &status) && status.Status().Code >= http.StatusInternalServerError
+}
+
+// firstWriteWriter calls onFirstWrite before the first write to w.
+type firstWriteWriter struct {
+	w            io.Writer
+	onFirstWrite func()
+	once         sync.Once
+}
+
+func (w *firstWriteWriter) Write(p []byte) (int, error) {
+	w.once.Do(w.onFirstWrite)
+	return w.w.Write(p)
+}
+
+// inputPump reads from an io.Reader on behalf of consecutive sessions, so that a session
+// that ended does not consume input meant for the next one.
+type inputPump struct {
+	ch  chan []byte
+	err error
+
+	mu      sync.Mutex
+	pending []byte
+}
+
+func newInputPump(r io.Reader) *inputPump {
+	p := &inputPump{ch: make(chan []byte)}
+	go func() {
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := r.Read(buf)
+			if n > 0 {
+				p.ch <- append([]byte(nil), buf[:n]...)
+			}
+			if err != nil {
+				p.err = err
+				close(p.ch)
+				return
+			}
+		}
+	}()
+	return p
+}
+
+// sessionReader reads the input of an inputPump until done is closed.
+// onRead is called on each read.
+type sessionReader struct {
+	pump   *inputPump
+	done   <-chan struct{}
+	onRead func()
+}
+
+func (r *sessionReader) Read(b []byte) (int, error) {
+	r.onRead()
+	if r.isDone() {
+		return 0, io.EOF
+	}
+
+	r.pump.mu.Lock()
+	if len(r.pump.pending) > 0 {
+		n := copy(b, r.pump.pending)
+		r.pump.pending = r.pump.pending[n:]
+		r.pump.mu.Unlock()
+		return n, nil
+	}
+	r.pump.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return 0, io.EOF
+	case data, ok := <-r.pump.ch:
+		if !ok {
+			return 0, r.pump.err
+		}
+
+		r.pump.mu.Lock()
+		defer r.pump.mu.Unlock()
+		if r.isDone() {
+			// Keep the data for the next session.
+			r.pump.pending = append(data, r.pump.pending...)
+			return 0, io.EOF
+		}
+		n := copy(b, data)
+		r.pump.pending = data[n:]
+		return n, nil
+	}
+}
+
+func (r *sessionReader) isDone() bool {
+	select {
+	case <-r.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// sizePump reads terminal sizes from a remotecommand.TerminalSizeQueue on behalf of consecutive sessions
+// and remembers the latest size to restore it for a new session.
+type sizePump struct {
+	ch chan *remotecommand.TerminalSize
+
+	mu     sync.Mutex
+	latest *remotecommand.TerminalSize
+}
+
+func newSizePump(queue remotecommand.TerminalSizeQueue) *sizePump {
+	p := &sizePump{ch: make(chan *remotecommand.TerminalSize, 1)}
+	go func() {
+		for {
+			size := queue.Next()
+			if size == nil {
+				close(p.ch)
+				return
+			}
+
+			p.mu.Lock()
+			p.latest = size
+			p.mu.Unlock()
+
+			select {
+			case p.ch <- size:
+			default:
+				// No session is receiving sizes, the latest size is restored for the next one.
+			}
+		}
+	}()
+	return p
+}
+
+// sessionQueue returns the remotecommand.TerminalSizeQueue of a session ending when done is closed.
+// If restore is set, the queue starts with the latest size, preceded by a slightly larger size so that
+// the screen is redrawn. onNext is called on each call of Next.
+func (p *sizePump) sessionQueue(done <-chan struct{}, restore bool, onNext func()) remotecommand.TerminalSizeQueue {
+	q := &sessionSizeQueue{pump: p, done: done, onNext: onNext}
+	if !restore {
+		return q
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.latest != nil {
+		sizePlusOne := *p.latest
+		sizePlusOne.Width++
+		sizePlusOne.Height++
+		q.initial = []*remotecommand.TerminalSize{&sizePlusOne, p.latest}
+	}
+	return q
+}
+
+type sessionSizeQueue struct {
+	pump    *sizePump
+	done    <-chan struct{}
+	initial []*remotecommand.TerminalSize
+	onNext  func()
+}
+
+func (q *sessionSizeQueue) Next() *remotecommand.TerminalSize {
+	q.onNext()
+	if len(q.initial) > 0 {
+		size := q.initial[0]
+		q.initial = q.initial[1:]
+		return size
+	}
+
+	select {
+	case <-q.done:
+		return nil
+	case size, ok := <-q.pump.ch:
+		if !ok {
+			return nil
+		}
+		select {
+		case <-q.done:
+			// Keep the size for the next session.
+			select {
+			case q.pump.ch <- size:
+			default:
+			}
+			return nil
+		default:
+			return size
+		}
+	}
+}
@@ -0,0 +1,331 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+func noop() {}
+
+// readN reads exactly n bytes from r, failing the test after testTimeout.
+func readN(t *testing.T, r io.Reader, n int) string {
+	t.Helper()
+
+	type result struct {
+		data []byte
+		err  error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		buf := make([]byte, n)
+		_, err := io.ReadFull(r, buf)
+		ch <- result{buf, err}
+	}()
+
+	select {
+	case res := <-ch:
+		if res.err != nil {
+			t.Fatalf("error reading: %v", res.err)
+		}
+		return string(res.data)
+	case <-time.After(testTimeout):
+		t.Fatalf("timed out reading %d bytes", n)
+		return ""
+	}
+}
+
+func TestSessionReaderPartialRead(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer func() { _ = pw.Close() }()
+	pump := newInputPump(pr)
+	go func() { _, _ = pw.Write([]byte("input")) }()
+
+	done := make(chan struct{})
+	first := &sessionReader{pump: pump, done: done, onRead: noop}
+	if data := readN(t, first, 2); data != "in" {
+		t.Errorf("expected first session to read %q, got %q", "in", data)
+	}
+
+	close(done)
+	if n, err := first.Read(make([]byte, 8)); n != 0 || err != io.EOF {
+		t.Errorf("expected ended session to read EOF, got %d, %v", n, err)
+	}
+
+	second := &sessionReader{pump: pump, done: make(chan struct{}), onRead: noop}
+	if data := readN(t, second, 3); data != "put" {
+		t.Errorf("expected next session to read the rest %q, got %q", "put", data)
+	}
+}
+
+func TestSessionReaderHandsOverInputOfEndedSession(t *testing.T) {
+	// The input arrives while the first session ends. Whether the first session receives it before
+	// noticing it ended (and re-queues it) or not, the next session has to get all of it.
+	for i := 0; i < 100; i++ {
+		pr, pw := io.Pipe()
+		pump := newInputPump(pr)
+
+		done := make(chan struct{})
+		first := &sessionReader{pump: pump, done: done, onRead: noop}
+		read := make(chan string, 1)
+		go func() {
+			buf := make([]byte, 8)
+			n, _ := first.Read(buf)
+			read <- string(buf[:n])
+		}()
+		go func() { _, _ = pw.Write([]byte("input")) }()
+		close(done)
+
+		var data string
+		select {
+		case data = <-read:
+		case <-time.After(testTimeout):
+			t.Fatal("timed out waiting for the ended session to return")
+		}
+		if data != "" {
+			t.Fatalf("expected ended session to read nothing, got %q", data)
+		}
+
+		second := &sessionReader{pump: pump, done: make(chan struct{}), onRead: noop}
+		if data := readN(t, second, 5); data != "input" {
+			t.Fatalf("expected next session to read %q, got %q", "input", data)
+		}
+		_ = pw.Close()
+	}
+}
+
+func TestSessionReaderInputError(t *testing.T) {
+	pump := newInputPump(strings.NewReader(""))
+	r := &sessionReader{pump: pump, done: make(chan struct{}), onRead: noop}
+	if _, err := r.Read(make([]byte, 8)); err != io.EOF {
+		t.Errorf("expected EOF of the input, got %v", err)
+	}
+}
+
+// chanSizeQueue is a remotecommand.TerminalSizeQueue returning the sizes sent to it until it is closed.
+type chanSizeQueue chan *remotecommand.TerminalSize
+
+func (q chanSizeQueue) Next() *remotecommand.TerminalSize {
+	return <-q
+}
+
+// nextSize returns the next size of q, failing the test after testTimeout.
+func nextSize(t *testing.T, q remotecommand.TerminalSizeQueue) *remotecommand.TerminalSize {
+	t.Helper()
+
+	ch := make(chan *remotecommand.TerminalSize, 1)
+	go func() { ch <- q.Next() }()
+	select {
+	case size := <-ch:
+		return size
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for the next terminal size")
+		return nil
+	}
+}
+
+func TestSizePumpRestoresLatestSize(t *testing.T) {
+	sizes := make(chanSizeQueue)
+	defer close(sizes)
+	pump := newSizePump(sizes)
+
+	done := make(chan struct{})
+	first := pump.sessionQueue(done, false, noop)
+	sizes <- &remotecommand.TerminalSize{Width: 80, Height: 24}
+	if size := nextSize(t, first); *size != (remotecommand.TerminalSize{Width: 80, Height: 24}) {
+		t.Errorf("expected first session to get 80x24, got %v", *size)
+	}
+
+	close(done)
+	if size := first.Next(); size != nil {
+		t.Errorf("expected ended session to get no size, got %v", *size)
+	}
+
+	// Resized while no session is running.
+	sizes <- &remotecommand.TerminalSize{Width: 100, Height: 30}
+	if err := wait.PollUntilContextTimeout(context.Background(), time.Millisecond, testTimeout, true, func(context.Context) (bool, error) {
+		pump.mu.Lock()
+		defer pump.mu.Unlock()
+		return pump.latest != nil && pump.latest.Width == 100, nil
+	}); err != nil {
+		t.Fatalf("error waiting for the latest size: %v", err)
+	}
+
+	second := pump.sessionQueue(make(chan struct{}), true, noop)
+	for _, expected := range []remotecommand.TerminalSize{{Width: 101, Height: 31}, {Width: 100, Height: 30}} {
+		if size := nextSize(t, second); size == nil || *size != expected {
+			t.Errorf("expected restored session to get %v, got %v", expected, size)
+		}
+	}
+}
+
+func TestSizePumpNoRestoreWithoutSize(t *testing.T) {
+	sizes := make(chanSizeQueue)
+	pump := newSizePump(sizes)
+
+	q := pump.sessionQueue(make(chan struct{}), true, noop)
+	close(sizes)
+	if size := nextSize(t, q); size != nil {
+		t.Errorf("expected no size to restore, got %v", *size)
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+var _ net.Error = timeoutError{}
+
+func TestIsRetryable(t *testing.T) {
+	resource := schema.GroupResource{Resource: "machines"}
+
+	for _, tc := range []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "eof", err: io.EOF, expected: true},
+		{name: "unexpected eof", err: fmt.Errorf("reading stream: %w", io.ErrUnexpectedEOF), expected: true},
+		{name: "net error", err: &net.OpError{Op: "dial", Net: "tcp", Err: timeoutError{}}, expected: true},
+		{name: "upgrade failure", err: &httpstream.UpgradeFailureError{Cause: errors.New("bad handshake")}, expected: true},
+		{name: "internal error", err: apierrors.NewInternalError(errors.New("boom")), expected: true},
+		{name: "service unavailable", err: apierrors.NewServiceUnavailable("restarting"), expected: true},
+		{name: "server timeout", err: apierrors.NewServerTimeout(resource, "exec", 1), expected: true},
+		{name: "too many requests", err: apierrors.NewTooManyRequests("slow down", 1), expected: true},
+		{name: "bad gateway", err: apierrors.NewGenericServerResponse(502, "GET", resource, "", "", 0, false), expected: true},
+		{name: "not found", err: apierrors.NewNotFound(resource, "my-machine")},
+		{name: "forbidden", err: apierrors.NewForbidden(resource, "my-machine", errors.New("denied"))},
+		{name: "unauthorized", err: apierrors.NewUnauthorized("expired")},
+		{name: "bad request", err: apierrors.NewBadRequest("invalid")},
+		{name: "conflict", err: apierrors.NewConflict(resource, "my-machine", errors.New("conflict"))},
+		{name: "other error", err: errors.New("command terminated with exit code 1")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := isRetryable(tc.err); actual != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+// fakeExecutor runs its sessions in order, each returning the result of its function.
+type fakeExecutor struct {
+	mu       sync.Mutex
+	sessions []func(opts remotecommand.StreamOptions) error
+	calls    int
+}
+
+func (e *fakeExecutor) Stream(opts remotecommand.StreamOptions) error {
+	return e.StreamWithContext(context.Background(), opts)
+}
+
+func (e *fakeExecutor) StreamWithContext(_ context.Context, opts remotecommand.StreamOptions) error {
+	e.mu.Lock()
+	session := e.sessions[min(e.calls, len(e.sessions)-1)]
+	e.calls++
+	e.mu.Unlock()
+	return session(opts)
+}
+
+func TestStreamWithReconnect(t *testing.T) {
+	const timeout = 20 * time.Millisecond
+
+	defer func(orig wait.Backoff) { ReconnectBackoff = orig }(ReconnectBackoff)
+	ReconnectBackoff = wait.Backoff{Duration: time.Millisecond, Factor: 1, Steps: 1000, Cap: time.Millisecond}
+
+	// established writes output, lasts longer than the reconnect timeout and then loses the connection.
+	established := func(opts remotecommand.StreamOptions) error {
+		_, _ = opts.Stdout.Write([]byte("x"))
+		time.Sleep(2 * timeout)
+		return io.ErrUnexpectedEOF
+	}
+	// unestablished fails to connect after longer than the reconnect timeout.
+	unestablished := func(remotecommand.StreamOptions) error {
+		time.Sleep(2 * timeout)
+		return &httpstream.UpgradeFailureError{Cause: errors.New("connection refused")}
+	}
+	succeeded := func(remotecommand.StreamOptions) error { return nil }
+
+	for _, tc := range []struct {
+		name                string
+		sessions            []func(remotecommand.StreamOptions) error
+		expectError         string
+		expectedCalls       int
+		expectedReconnected int
+	}{
+		{
+			name:                "timeout is reset after established sessions",
+			sessions:            []func(remotecommand.StreamOptions) error{established, established, established, succeeded},
+			expectedCalls:       4,
+			expectedReconnected: 2,
+		},
+		{
+			name:          "gives up after timeout without established session",
+			sessions:      []func(remotecommand.StreamOptions) error{established, unestablished},
+			expectError:   "could not reconnect within",
+			expectedCalls: 2,
+		},
+		{
+			name: "does not retry non-retryable error",
+			sessions: []func(remotecommand.StreamOptions) error{func(remotecommand.StreamOptions) error {
+				return apierrors.NewNotFound(schema.GroupResource{Resource: "machines"}, "my-machine")
+			}},
+			expectError:   "not found",
+			expectedCalls: 1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			exec := &fakeExecutor{sessions: tc.sessions}
+
+			var (
+				mu       sync.Mutex
+				statuses []string
+			)
+			status := func(format string, args ...any) {
+				mu.Lock()
+				defer mu.Unlock()
+				statuses = append(statuses, fmt.Sprintf(format, args...))
+			}
+
+			err := streamWithReconnect(context.Background(), exec, remotecommand.StreamOptions{Stdout: io.Discard}, timeout, status)
+			if tc.expectError == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tc.expectError != "" && (err == nil || !strings.Contains(err.Error(), tc.expectError)) {
+				t.Errorf("expected an error containing %q, got %v", tc.expectError, err)
+			}
+			if exec.calls != tc.expectedCalls {
+				t.Errorf("expected %d sessions, got %d", tc.expectedCalls, exec.calls)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			var reconnected int
+			for _, s := range statuses {
+				if s == "Reconnected." {
+					reconnected++
+				}
+			}
+			if reconnected != tc.expectedReconnected {
+				t.Errorf("expected %d reconnected statuses, got %d (%q)", tc.expectedReconnected, reconnected, statuses)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package exec
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ironcore-dev/kubectl-ironcore/asciicast"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// createRecording creates the given file and writes the given asciicast header to it.
+// The file is only readable by the current user as it may contain sensitive input.
+func createRecording(filename string, header asciicast.Header) (*asciicast.Writer, *os.File, error) {
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating recording: %w", err)
+	}
+	// The permissions are only applied when creating the file, restrict the ones of an existing file.
+	if err := f.Chmod(0600); err != nil {
+		_ = f.Close()
+		return nil, nil, fmt.Errorf("error creating recording: %w", err)
+	}
+
+	rec, err := asciicast.NewWriter(f, header)
+	if err != nil {
+		_ = f.Close()
+		return nil, nil, fmt.Errorf("error writing recording: %w", err)
+	}
+	return rec, f, nil
+}
+
+// recordingSizeQueue records the terminal sizes returned by the underlying queue as resize events.
+// Sizes not differing from the last recorded one and the synthetic size sent once to force a redraw
+// are not recorded.
+type recordingSizeQueue struct {
+	remotecommand.TerminalSizeQueue
+	rec       *asciicast.Writer
+	last      remotecommand.TerminalSize
+	synthetic *remotecommand.TerminalSize
+}
+
+func (q *recordingSizeQueue) Next() *remotecommand.TerminalSize {
+	size := q.TerminalSizeQueue.Next()
+	switch {
+	case size == nil:
+	case q.synthetic != nil && *size == *q.synthetic:
+		q.synthetic = nil
+	case *size != q.last:
+		q.last = *size
+		_ = q.rec.WriteResize(int(size.Width), int(size.Height))
+	}
+	return size
+}
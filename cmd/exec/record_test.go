@@ -0,0 +1,33 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package exec
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ironcore-dev/kubectl-ironcore/asciicast"
+)
+
+func TestCreateRecordingRestrictsExistingFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "session.cast")
+	if err := os.WriteFile(filename, []byte("old recording"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, f, err := createRecording(filename, asciicast.Header{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("expected permissions 0600, got %#o", perm)
+	}
+}
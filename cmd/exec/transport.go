@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package exec
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+const (
+	// TransportAuto uses WebSocket and falls back to SPDY if the WebSocket upgrade fails
+	// or if WebSocket cannot be used with the configured HTTPS proxy.
+	TransportAuto = "auto"
+	// TransportWebSocket only uses WebSocket.
+	TransportWebSocket = "websocket"
+	// TransportSPDY only uses SPDY.
+	TransportSPDY = "spdy"
+)
+
+// Transports are the available transports.
+var Transports = []string{TransportAuto, TransportWebSocket, TransportSPDY}
+
+// NewExecutor creates an executor streaming to the given url using the given transport.
+func NewExecutor(cfg *rest.Config, transport string, u *url.URL) (remotecommand.Executor, error) {
+	switch transport {
+	case TransportWebSocket:
+		return remotecommand.NewWebSocketExecutor(cfg, http.MethodGet, u.String())
+	case TransportSPDY:
+		return remotecommand.NewSPDYExecutor(cfg, http.MethodPost, u)
+	case TransportAuto:
+		websocketExec, err := remotecommand.NewWebSocketExecutor(cfg, http.MethodGet, u.String())
+		if err != nil {
+			return nil, err
+		}
+		spdyExec, err := remotecommand.NewSPDYExecutor(cfg, http.MethodPost, u)
+		if err != nil {
+			return nil, err
+		}
+		return remotecommand.NewFallbackExecutor(websocketExec, spdyExec, shouldFallback)
+	default:
+		return nil, fmt.Errorf("unknown transport %q, must be one of %v", transport, Transports)
+	}
+}
+
+// shouldFallback reports whether to fall back to SPDY after the given WebSocket error.
+func shouldFallback(err error) bool {
+	return httpstream.IsUpgradeFailure(err) || isHTTPSProxyError(err)
+}
+
+// isHTTPSProxyError reports whether the given error is caused by the WebSocket dialer not supporting HTTPS proxies.
+// It mirrors httpstream.IsHTTPSProxyError of newer apimachinery versions.
+func isHTTPSProxyError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "proxy: unknown scheme: https")
+}
@@ -0,0 +1,347 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package exec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apimachinery/pkg/util/httpstream/spdy"
+	"k8s.io/apimachinery/pkg/util/httpstream/wsstream"
+	utilremotecommand "k8s.io/apimachinery/pkg/util/remotecommand"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+const (
+	websocketOutput = "output via websocket"
+	spdyOutput      = "output via spdy"
+
+	testTimeout = 10 * time.Second
+)
+
+var successStatus = []byte(`{"metadata":{},"status":"Success"}`)
+
+// execServer answers exec requests with an output naming the transport used.
+// Requests of disabled transports are rejected before upgrading.
+type execServer struct {
+	t *testing.T
+
+	websocket bool
+	spdy      bool
+
+	websocketRequests atomic.Int32
+	spdyRequests      atomic.Int32
+}
+
+func (s *execServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if wsstream.IsWebSocketRequest(req) {
+		s.websocketRequests.Add(1)
+		if !s.websocket {
+			http.Error(w, "websocket is not supported", http.StatusBadRequest)
+			return
+		}
+		s.serveWebSocket(w, req)
+		return
+	}
+
+	s.spdyRequests.Add(1)
+	if !s.spdy {
+		http.Error(w, "spdy is not supported", http.StatusBadRequest)
+		return
+	}
+	s.serveSPDY(w, req)
+}
+
+// serveWebSocket speaks the v5.channel.k8s.io protocol: stdin, stdout, stderr, error and resize channels.
+func (s *execServer) serveWebSocket(w http.ResponseWriter, req *http.Request) {
+	conn := wsstream.NewConn(map[string]wsstream.ChannelProtocolConfig{
+		utilremotecommand.StreamProtocolV5Name: {
+			Binary: true,
+			Channels: []wsstream.ChannelType{
+				wsstream.ReadChannel,
+				wsstream.WriteChannel,
+				wsstream.WriteChannel,
+				wsstream.WriteChannel,
+				wsstream.ReadChannel,
+			},
+		},
+	})
+	_, channels, err := conn.Open(w, req)
+	if err != nil {
+		s.t.Errorf("error opening websocket connection: %v", err)
+		return
+	}
+	defer func() { _ = conn.Close() }()
+
+	if _, err := channels[1].Write([]byte(websocketOutput)); err != nil {
+		s.t.Errorf("error writing stdout: %v", err)
+		return
+	}
+	if _, err := channels[3].Write(successStatus); err != nil {
+		s.t.Errorf("error writing status: %v", err)
+	}
+}
+
+// serveSPDY speaks the v4.channel.k8s.io protocol, expecting the client to only create the error and stdout streams.
+func (s *execServer) serveSPDY(w http.ResponseWriter, req *http.Request) {
+	if _, err := httpstream.Handshake(req, w, []string{utilremotecommand.StreamProtocolV4Name}); err != nil {
+		s.t.Errorf("error negotiating spdy protocol: %v", err)
+		return
+	}
+
+	type newStream struct {
+		stream    httpstream.Stream
+		replySent <-chan struct{}
+	}
+	newStreams := make(chan newStream, 2)
+	conn := spdy.NewResponseUpgrader().UpgradeResponse(w, req, func(stream httpstream.Stream, replySent <-chan struct{}) error {
+		newStreams <- newStream{stream, replySent}
+		return nil
+	})
+	if conn == nil {
+		s.t.Error("error upgrading spdy connection")
+		return
+	}
+	defer func() { _ = conn.Close() }()
+
+	streams := make(map[string]httpstream.Stream)
+	for len(streams) < 2 {
+		select {
+		case ns := <-newStreams:
+			<-ns.replySent
+			streams[ns.stream.Headers().Get(corev1.StreamType)] = ns.stream
+		case <-time.After(testTimeout):
+			s.t.Errorf("timed out waiting for spdy streams, got %d", len(streams))
+			return
+		}
+	}
+
+	for streamType, data := range map[string][]byte{
+		corev1.StreamTypeStdout: []byte(spdyOutput),
+		corev1.StreamTypeError:  successStatus,
+	} {
+		stream, ok := streams[streamType]
+		if !ok {
+			s.t.Errorf("missing %s stream", streamType)
+			return
+		}
+		if _, err := stream.Write(data); err != nil {
+			s.t.Errorf("error writing %s stream: %v", streamType, err)
+			return
+		}
+		_ = stream.Close()
+	}
+
+	select {
+	case <-conn.CloseChan():
+	case <-time.After(testTimeout):
+	}
+}
+
+// newHTTPSProxy starts an HTTPS proxy tunneling CONNECT requests and returns its URL and the number of tunnels.
+func newHTTPSProxy(t *testing.T) (*url.URL, *atomic.Int32) {
+	tunnels := &atomic.Int32{}
+	proxy := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodConnect {
+			http.Error(w, "only CONNECT is supported", http.StatusMethodNotAllowed)
+			return
+		}
+		tunnels.Add(1)
+
+		target, err := net.Dial("tcp", req.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		conn, _, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			_ = target.Close()
+			t.Errorf("error hijacking proxy connection: %v", err)
+			return
+		}
+		_, _ = fmt.Fprint(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+
+		go func() {
+			_, _ = io.Copy(target, conn)
+			_ = target.Close()
+		}()
+		_, _ = io.Copy(conn, target)
+		_ = conn.Close()
+	}))
+	t.Cleanup(proxy.Close)
+
+	u, err := url.Parse(proxy.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u, tunnels
+}
+
+func TestNewExecutor(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		transport string
+		websocket bool
+		spdy      bool
+		// httpsProxy connects through an HTTPS proxy, which only SPDY supports.
+		httpsProxy bool
+
+		expectedOutput            string
+		expectedWebSocketRequests int32
+		expectedSPDYRequests      int32
+	}{
+		{
+			name:                      "auto prefers websocket",
+			transport:                 TransportAuto,
+			websocket:                 true,
+			spdy:                      true,
+			expectedOutput:            websocketOutput,
+			expectedWebSocketRequests: 1,
+		},
+		{
+			name:                      "auto falls back to spdy",
+			transport:                 TransportAuto,
+			spdy:                      true,
+			expectedOutput:            spdyOutput,
+			expectedWebSocketRequests: 1,
+			expectedSPDYRequests:      1,
+		},
+		{
+			name:                 "auto falls back to spdy with https proxy",
+			transport:            TransportAuto,
+			websocket:            true,
+			spdy:                 true,
+			httpsProxy:           true,
+			expectedOutput:       spdyOutput,
+			expectedSPDYRequests: 1,
+		},
+		{
+			name:                      "websocket",
+			transport:                 TransportWebSocket,
+			websocket:                 true,
+			spdy:                      true,
+			expectedOutput:            websocketOutput,
+			expectedWebSocketRequests: 1,
+		},
+		{
+			name:                      "websocket does not fall back",
+			transport:                 TransportWebSocket,
+			spdy:                      true,
+			expectedWebSocketRequests: 1,
+		},
+		{
+			name:       "websocket does not fall back with https proxy",
+			transport:  TransportWebSocket,
+			websocket:  true,
+			spdy:       true,
+			httpsProxy: true,
+		},
+		{
+			name:                 "spdy",
+			transport:            TransportSPDY,
+			websocket:            true,
+			spdy:                 true,
+			expectedOutput:       spdyOutput,
+			expectedSPDYRequests: 1,
+		},
+		{
+			name:                 "spdy does not fall back",
+			transport:            TransportSPDY,
+			websocket:            true,
+			expectedSPDYRequests: 1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := &execServer{t: t, websocket: tc.websocket, spdy: tc.spdy}
+			server := httptest.NewServer(srv)
+			defer server.Close()
+
+			u, err := url.Parse(server.URL + "/exec")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cfg := &rest.Config{Host: server.URL}
+			var tunnels *atomic.Int32
+			if tc.httpsProxy {
+				var proxyURL *url.URL
+				proxyURL, tunnels = newHTTPSProxy(t)
+				cfg.Proxy = http.ProxyURL(proxyURL)
+				cfg.TLSClientConfig.Insecure = true
+			}
+
+			exec, err := NewExecutor(cfg, tc.transport, u)
+			if err != nil {
+				t.Fatalf("error creating executor: %v", err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+			defer cancel()
+
+			stdout := &bytes.Buffer{}
+			err = exec.StreamWithContext(ctx, remotecommand.StreamOptions{Stdout: stdout})
+			if tc.expectedOutput == "" {
+				if err == nil {
+					t.Errorf("expected an error, got output %q", stdout.String())
+				}
+			} else {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				if output := stdout.String(); output != tc.expectedOutput {
+					t.Errorf("expected output %q, got %q", tc.expectedOutput, output)
+				}
+			}
+
+			if n := srv.websocketRequests.Load(); n != tc.expectedWebSocketRequests {
+				t.Errorf("expected %d websocket requests, got %d", tc.expectedWebSocketRequests, n)
+			}
+			if n := srv.spdyRequests.Load(); n != tc.expectedSPDYRequests {
+				t.Errorf("expected %d spdy requests, got %d", tc.expectedSPDYRequests, n)
+			}
+			if tunnels != nil && tunnels.Load() != tc.expectedSPDYRequests {
+				t.Errorf("expected %d proxy tunnels, got %d", tc.expectedSPDYRequests, tunnels.Load())
+			}
+		})
+	}
+}
+
+func TestNewExecutorUnknownTransport(t *testing.T) {
+	u := &url.URL{Scheme: "https", Host: "example.com", Path: "/exec"}
+	if _, err := NewExecutor(&rest.Config{Host: u.Host}, "telnet", u); err == nil {
+		t.Error("expected an error for an unknown transport")
+	}
+}
+
+func TestShouldFallback(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "no error"},
+		{name: "upgrade failure", err: fmt.Errorf("dial: %w", &httpstream.UpgradeFailureError{Cause: errors.New("bad handshake")}), expected: true},
+		{name: "https proxy", err: errors.New("proxy: unknown scheme: https"), expected: true},
+		{name: "other error", err: errors.New("connection reset by peer")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := shouldFallback(tc.err); actual != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -5,73 +5,293 @@ package bootstrapkubeconfig
 
 import (
 	"bytes"
+	"context"
+	"encoding/base64"
 	"fmt"
 	"io"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/ironcore-dev/kubectl-ironcore/bootstrapkubeconfig"
+	"github.com/ironcore-dev/kubectl-ironcore/bootstraptoken"
+	"github.com/ironcore-dev/kubectl-ironcore/utils/apply"
 	utilbootstraptoken "github.com/ironcore-dev/kubectl-ironcore/utils/bootstraptoken"
+	"github.com/ironcore-dev/kubectl-ironcore/utils/kubeconfig"
 	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/cluster-bootstrap/token/util"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 type Flags struct {
-	Filename     string
-	NoFlatten    bool
-	ConfigAccess clientcmd.ConfigAccess
+	Filename                 string
+	NoFlatten                bool
+	Server                   string
+	CertificateAuthority     string
+	CertificateAuthorityData string
+	TLSServerName            string
+	ProxyURL                 string
+	Token                    string
+	TokenID                  string
+	TokenType                bootstraptoken.Type
+	PoolName                 string
+	DiscoveryServer          string
+	DiscoveryCACertHashes    []string
+	DiscoveryUnsafeSkipCA    bool
+	SecretName               string
+	SecretNamespace          string
+	SecretKey                string
+	Apply                    bool
+	ApplyContext             string
+	RESTClientGetter         genericclioptions.RESTClientGetter
+	PrintFlags               *genericclioptions.PrintFlags
 	genericclioptions.IOStreams
 }
 
-func NewFlags(configAccess clientcmd.ConfigAccess, streams genericclioptions.IOStreams) *Flags {
+// secretOutputFormat is the output format to print the bootstrap kubeconfig wrapped in a secret as yaml.
+const secretOutputFormat = "secret"
+
+func NewFlags(restClientGetter genericclioptions.RESTClientGetter, streams genericclioptions.IOStreams) *Flags {
+	printFlags := genericclioptions.NewPrintFlags("").
+		WithTypeSetter(scheme.Scheme)
+
 	return &Flags{
-		ConfigAccess: configAccess,
-		IOStreams:    streams,
+		RESTClientGetter: restClientGetter,
+		PrintFlags:       printFlags,
+		IOStreams:        streams,
 	}
 }
 
 func (f *Flags) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&f.Filename, "filename", "f", "", "File to read for bootstrap token secret. Specify '-' for using stdin.")
 	cmd.Flags().BoolVar(&f.NoFlatten, "no-flatten", false, "Whether to skip flattening of the resulting kubeconfig.")
+	cmd.Flags().StringVar(&f.Server, "bootstrap-server", "", "Server address to use in the bootstrap kubeconfig instead of the one of the kubeconfig context.")
+	cmd.Flags().StringVar(&f.CertificateAuthority, "bootstrap-certificate-authority", "", "Path to a certificate authority file to use in the bootstrap kubeconfig instead of the one of the kubeconfig context.")
+	cmd.Flags().StringVar(&f.CertificateAuthorityData, "bootstrap-certificate-authority-data", "", "Base64-encoded certificate authority data to use in the bootstrap kubeconfig instead of the one of the kubeconfig context.")
+	cmd.Flags().StringVar(&f.TLSServerName, "bootstrap-tls-server-name", "", "TLS server name to use in the bootstrap kubeconfig instead of the one of the kubeconfig context.")
+	cmd.Flags().StringVar(&f.ProxyURL, "bootstrap-proxy-url", "", "Proxy url to use in the bootstrap kubeconfig instead of the one of the kubeconfig context.")
+	cmd.Flags().StringVar(&f.Token, "bootstrap-token", "", "Bootstrap token of the form 'id.secret' to use instead of reading a bootstrap token secret.")
+	cmd.Flags().StringVar(&f.TokenID, "token-id", "", "ID of a bootstrap token to fetch from the cluster of the kubeconfig context.")
+	bootstraptoken.AddTypeFlag(cmd, &f.TokenType, "Type of the bootstrap token to fetch from the cluster of the kubeconfig context. The newest valid token of the type is used.")
+	cmd.Flags().StringVar(&f.PoolName, "pool-name", "", "Name of the pool the token to fetch has to be scoped to. Requires --token-type. If unset, tokens scoped to a pool are skipped.")
+	cmd.Flags().StringVar(&f.DiscoveryServer, "discovery-server", "", "Address of the API server to discover the cluster from via its cluster-info ConfigMap instead of using the kubeconfig.")
+	cmd.Flags().StringSliceVar(&f.DiscoveryCACertHashes, "discovery-token-ca-cert-hash", nil, "Public key pins ('sha256:<hex>') the discovered cluster CA has to match.")
+	cmd.Flags().BoolVar(&f.DiscoveryUnsafeSkipCA, "discovery-token-unsafe-skip-ca-verification", false, "Whether to trust the discovered cluster CA without verifying it against --discovery-token-ca-cert-hash.")
+	cmd.Flags().StringVar(&f.SecretName, "secret-name", bootstrapkubeconfig.DefaultSecretName, "Name of the secret to wrap the bootstrap kubeconfig in.")
+	cmd.Flags().StringVar(&f.SecretNamespace, "secret-namespace", "", "Namespace of the secret to wrap the bootstrap kubeconfig in. If unset, the namespace of the kubeconfig context is used.")
+	cmd.Flags().StringVar(&f.SecretKey, "secret-key", bootstrapkubeconfig.DefaultSecretKey, "Key of the secret to store the bootstrap kubeconfig under.")
+	cmd.Flags().BoolVar(&f.Apply, "apply", false, "Whether to server-side apply the secret containing the bootstrap kubeconfig.")
+	cmd.Flags().StringVar(&f.ApplyContext, "apply-context", "", "Kubeconfig context of the cluster to apply the secret to. If unset, the kubeconfig context is used.")
+	cmdutil.AddDryRunFlag(cmd)
+	f.PrintFlags.AddFlags(cmd)
+
+	outputFlag := cmd.Flags().Lookup("output")
+	outputFlag.Usage = fmt.Sprintf("Output format to print the bootstrap kubeconfig wrapped in a secret with. One of: (%s). If unset, the plain bootstrap kubeconfig is printed.",
+		strings.Join(append([]string{secretOutputFormat}, f.PrintFlags.AllowedFormats()...), ", "))
 }
 
-func (f *Flags) ToOptions() (*Options, error) {
-	if f.Filename == "" {
-		return nil, fmt.Errorf("must specify filename")
+func (f *Flags) ToOptions(cmd *cobra.Command) (*Options, error) {
+	var numSources int
+	for _, set := range []bool{f.Filename != "", f.Token != "", f.TokenID != "", f.TokenType != ""} {
+		if set {
+			numSources++
+		}
+	}
+	if numSources == 0 {
+		return nil, fmt.Errorf("must specify one of filename, --bootstrap-token, --token-id or --token-type")
+	}
+	if numSources > 1 {
+		return nil, fmt.Errorf("can only specify one of filename, --bootstrap-token, --token-id or --token-type")
+	}
+
+	var tokenID string
+	if f.TokenID != "" {
+		var err error
+		tokenID, err = utilbootstraptoken.ParseIDOrToken(f.TokenID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if f.TokenType != "" && !bootstraptoken.AvailableTypes.Has(f.TokenType) {
+		return nil, fmt.Errorf("unknown type %q", f.TokenType)
+	}
+	if f.PoolName != "" {
+		if f.TokenType == "" {
+			return nil, fmt.Errorf("--pool-name requires --token-type")
+		}
+		if _, err := bootstraptoken.PoolGroup(f.TokenType, f.PoolName); err != nil {
+			return nil, err
+		}
+	}
+	if f.DiscoveryServer == "" && (len(f.DiscoveryCACertHashes) > 0 || f.DiscoveryUnsafeSkipCA) {
+		return nil, fmt.Errorf("discovery flags require --discovery-server")
+	}
+
+	var token *utilbootstraptoken.BootstrapToken
+	if f.Token != "" {
+		id, secret, err := utilbootstraptoken.ParseToken(f.Token)
+		if err != nil {
+			return nil, err
+		}
+
+		token = &utilbootstraptoken.BootstrapToken{ID: id, Secret: secret}
+	}
+	if f.CertificateAuthority != "" && f.CertificateAuthorityData != "" {
+		return nil, fmt.Errorf("cannot specify both --bootstrap-certificate-authority and --bootstrap-certificate-authority-data")
+	}
+
+	var certificateAuthorityData []byte
+	if f.CertificateAuthorityData != "" {
+		var err error
+		certificateAuthorityData, err = base64.StdEncoding.DecodeString(f.CertificateAuthorityData)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding certificate authority data: %w", err)
+		}
+	}
+
+	contextName, _ := cmd.Flags().GetString(clientcmd.FlagContext)
+
+	dryRunStrategy, err := cmdutil.GetDryRunStrategy(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		printer         printers.ResourcePrinter
+		secretNamespace string
+	)
+	if outputFormat := *f.PrintFlags.OutputFormat; outputFormat != "" || f.Apply {
+		if f.NoFlatten {
+			return nil, fmt.Errorf("cannot wrap a bootstrap kubeconfig that is not flattened in a secret")
+		}
+		if f.SecretName == "" || f.SecretKey == "" {
+			return nil, fmt.Errorf("must specify --secret-name and --secret-key")
+		}
+
+		if outputFormat == secretOutputFormat {
+			*f.PrintFlags.OutputFormat = "yaml"
+		}
+		if f.Apply {
+			f.PrintFlags.NamePrintFlags.Operation = "serverside-applied"
+		}
+		cmdutil.PrintFlagsWithDryRunStrategy(f.PrintFlags, dryRunStrategy)
+		printer, err = f.PrintFlags.ToPrinter()
+		if err != nil {
+			return nil, err
+		}
+
+		secretNamespace = f.SecretNamespace
+		if secretNamespace == "" {
+			secretNamespace, err = kubeconfig.Namespace(f.RESTClientGetter, f.ApplyContext)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	newClient := func() (client.Client, error) {
+		return kubeconfig.NewClient(f.RESTClientGetter, "")
+	}
+	newApplyClient := func() (client.Client, error) {
+		return kubeconfig.NewClient(f.RESTClientGetter, f.ApplyContext)
 	}
 
 	return &Options{
-		Filename:     f.Filename,
-		ConfigAccess: f.ConfigAccess,
-		IOStreams:    f.IOStreams,
-		NoFlatten:    f.NoFlatten,
+		Filename:                 f.Filename,
+		ConfigAccess:             f.RESTClientGetter.ToRawKubeConfigLoader().ConfigAccess(),
+		Context:                  contextName,
+		Server:                   f.Server,
+		CertificateAuthority:     f.CertificateAuthority,
+		CertificateAuthorityData: certificateAuthorityData,
+		TLSServerName:            f.TLSServerName,
+		ProxyURL:                 f.ProxyURL,
+		Token:                    token,
+		TokenID:                  tokenID,
+		TokenType:                f.TokenType,
+		PoolName:                 f.PoolName,
+		NewClient:                newClient,
+		Printer:                  printer,
+		SecretName:               f.SecretName,
+		SecretNamespace:          secretNamespace,
+		SecretKey:                f.SecretKey,
+		Apply:                    f.Apply,
+		DryRun:                   dryRunStrategy,
+		NewApplyClient:           newApplyClient,
+		DiscoveryServer:          f.DiscoveryServer,
+		DiscoveryCACertHashes:    f.DiscoveryCACertHashes,
+		DiscoveryUnsafeSkipCA:    f.DiscoveryUnsafeSkipCA,
+		IOStreams:                f.IOStreams,
+		NoFlatten:                f.NoFlatten,
 	}, nil
 }
 
 type Options struct {
-	Filename     string
-	ConfigAccess clientcmd.ConfigAccess
-	Context      string
+	Filename                 string
+	ConfigAccess             clientcmd.ConfigAccess
+	Context                  string
+	Server                   string
+	CertificateAuthority     string
+	CertificateAuthorityData []byte
+	TLSServerName            string
+	ProxyURL                 string
+
+	// Token is the bootstrap token to use. If nil, the token is fetched from the cluster if TokenID or TokenType
+	// is set and read from a secret in Filename otherwise.
+	Token *utilbootstraptoken.BootstrapToken
+
+	// TokenID is the id of the bootstrap token secret to fetch from the cluster.
+	TokenID string
+	// TokenType is the type of the bootstrap token secrets to select the newest valid one from.
+	TokenType bootstraptoken.Type
+	// PoolName is the name of the pool the bootstrap token secrets of TokenType have to be scoped to.
+	// If empty, only tokens not scoped to any pool are selected.
+	PoolName  string
+	NewClient func() (client.Client, error)
+
+	// Printer prints the bootstrap kubeconfig wrapped in a secret of SecretName in SecretNamespace under SecretKey.
+	// If nil, the plain bootstrap kubeconfig is printed.
+	Printer         printers.ResourcePrinter
+	SecretName      string
+	SecretNamespace string
+	SecretKey       string
+
+	// Apply instructs to server-side apply the secret using a client created by NewApplyClient.
+	Apply          bool
+	DryRun         cmdutil.DryRunStrategy
+	NewApplyClient func() (client.Client, error)
+
+	// DiscoveryServer is the server to discover the cluster from instead of using ConfigAccess.
+	DiscoveryServer       string
+	DiscoveryCACertHashes []string
+	DiscoveryUnsafeSkipCA bool
+
 	genericclioptions.IOStreams
 	NoFlatten bool
 }
 
-func Command(configAccess clientcmd.ConfigAccess, streams genericclioptions.IOStreams) *cobra.Command {
-	flags := NewFlags(configAccess, streams)
+func Command(restClientGetter genericclioptions.RESTClientGetter, streams genericclioptions.IOStreams) *cobra.Command {
+	flags := NewFlags(restClientGetter, streams)
 
 	cmd := &cobra.Command{
 		Use:   "bootstrap-kubeconfig",
-		Short: "Generate a bootstrap-kubeconfig from a bootstrap-token secret and a kubeconfig.",
+		Short: "Generate a bootstrap-kubeconfig from a bootstrap token and a kubeconfig or cluster discovery.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			opts, err := flags.ToOptions()
+			opts, err := flags.ToOptions(cmd)
 			if err != nil {
 				return err
 			}
 
-			return Run(*opts)
+			return Run(cmd.Context(), *opts)
 		},
 	}
 
@@ -87,28 +307,144 @@ func readFileOrStdin(filename string, streams genericclioptions.IOStreams) ([]by
 	return os.ReadFile(filename)
 }
 
-func Run(opts Options) error {
-	secretData, err := readFileOrStdin(opts.Filename, opts.IOStreams)
+func readTokenSecret(filename string, streams genericclioptions.IOStreams) (*utilbootstraptoken.BootstrapToken, error) {
+	secretData, err := readFileOrStdin(filename, streams)
 	if err != nil {
-		return fmt.Errorf("error reading secret: %w", err)
+		return nil, fmt.Errorf("error reading secret: %w", err)
 	}
 
 	secret := &corev1.Secret{}
 	if err := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(secretData), 4096).Decode(secret); err != nil {
-		return fmt.Errorf("error decoding secret: %w", err)
+		return nil, fmt.Errorf("error decoding secret: %w", err)
+	}
+
+	token, err := utilbootstraptoken.FromSecret(secret)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding bootstrap token from secret: %w", err)
+	}
+	return token, nil
+}
+
+func isExpired(token *utilbootstraptoken.BootstrapToken, now time.Time) bool {
+	return token.Expires != nil && !token.Expires.After(now)
+}
+
+func fetchTokenSecret(ctx context.Context, c client.Reader, id string) (*utilbootstraptoken.BootstrapToken, error) {
+	secret := &corev1.Secret{}
+	key := client.ObjectKey{Namespace: metav1.NamespaceSystem, Name: util.BootstrapTokenSecretName(id)}
+	if err := c.Get(ctx, key, secret); err != nil {
+		return nil, fmt.Errorf("error getting bootstrap token secret: %w", err)
 	}
 
 	token, err := utilbootstraptoken.FromSecret(secret)
 	if err != nil {
-		return fmt.Errorf("error decoding bootstrap token from secret: %w", err)
+		return nil, fmt.Errorf("error decoding bootstrap token from secret: %w", err)
+	}
+	if isExpired(token, time.Now()) {
+		return nil, fmt.Errorf("bootstrap token %s has expired at %s", id, token.Expires.Format(time.RFC3339))
+	}
+	return token, nil
+}
+
+// findNewestToken returns the newest unexpired bootstrap token of the given type scoped to the pool of the given name.
+// If poolName is empty, tokens scoped to any pool are skipped.
+func findNewestToken(ctx context.Context, c client.Reader, typ bootstraptoken.Type, poolName string) (*utilbootstraptoken.BootstrapToken, error) {
+	tokenSecrets, err := utilbootstraptoken.ListSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		now         = time.Now()
+		newest      *utilbootstraptoken.BootstrapToken
+		newestSince time.Time
+	)
+	for i := range tokenSecrets {
+		secret := &tokenSecrets[i]
+		token, err := utilbootstraptoken.FromSecret(secret)
+		if err != nil || isExpired(token, now) || !bootstraptoken.HasType(token, typ) {
+			continue
+		}
+		if poolNames := bootstraptoken.PoolNamesOfGroups(typ, token.Groups); !matchesPool(poolNames, poolName) {
+			continue
+		}
+
+		if created := secret.CreationTimestamp.Time; newest == nil || created.After(newestSince) {
+			newest = token
+			newestSince = created
+		}
+	}
+	if newest == nil {
+		if poolName != "" {
+			return nil, fmt.Errorf("no valid bootstrap token of type %s for pool %s found", typ, poolName)
+		}
+		return nil, fmt.Errorf("no valid bootstrap token of type %s without pool scope found", typ)
+	}
+	return newest, nil
+}
+
+// matchesPool reports whether a token scoped to the given pools may be used for the pool of the given name.
+// Without a pool name, only tokens not scoped to any pool match.
+func matchesPool(poolNames []string, poolName string) bool {
+	if poolName == "" {
+		return len(poolNames) == 0
+	}
+	return slices.Contains(poolNames, poolName)
+}
+
+func getToken(ctx context.Context, opts Options) (*utilbootstraptoken.BootstrapToken, error) {
+	switch {
+	case opts.Token != nil:
+		return opts.Token, nil
+	case opts.TokenID != "" || opts.TokenType != "":
+		c, err := opts.NewClient()
+		if err != nil {
+			return nil, fmt.Errorf("error creating client: %w", err)
+		}
+
+		if opts.TokenID != "" {
+			return fetchTokenSecret(ctx, c, opts.TokenID)
+		}
+		return findNewestToken(ctx, c, opts.TokenType, opts.PoolName)
+	default:
+		return readTokenSecret(opts.Filename, opts.IOStreams)
 	}
+}
 
-	startingCfg, err := opts.ConfigAccess.GetStartingConfig()
+func Run(ctx context.Context, opts Options) error {
+	token, err := getToken(ctx, opts)
 	if err != nil {
 		return err
 	}
 
-	apiCfg, err := bootstrapkubeconfig.Generate(startingCfg, token, bootstrapkubeconfig.WithContext(opts.Context))
+	var (
+		startingCfg *clientcmdapi.Config
+		contextName = opts.Context
+	)
+	if opts.DiscoveryServer != "" {
+		startingCfg, err = bootstrapkubeconfig.Discover(ctx, opts.DiscoveryServer, token,
+			bootstrapkubeconfig.WithCACertHashes(opts.DiscoveryCACertHashes...),
+			bootstrapkubeconfig.WithUnsafeSkipCAVerification(opts.DiscoveryUnsafeSkipCA),
+		)
+		if err != nil {
+			return fmt.Errorf("error discovering cluster: %w", err)
+		}
+		contextName = bootstrapkubeconfig.DiscoveryContext
+	} else {
+		startingCfg, err = opts.ConfigAccess.GetStartingConfig()
+		if err != nil {
+			return err
+		}
+	}
+
+	apiCfg, err := bootstrapkubeconfig.Generate(startingCfg, token,
+		bootstrapkubeconfig.WithContext(contextName),
+		bootstrapkubeconfig.WithServer(opts.Server),
+		bootstrapkubeconfig.WithCertificateAuthority(opts.CertificateAuthority),
+		bootstrapkubeconfig.WithCertificateAuthorityData(opts.CertificateAuthorityData),
+		bootstrapkubeconfig.WithTLSServerName(opts.TLSServerName),
+		bootstrapkubeconfig.WithProxyURL(opts.ProxyURL),
+	)
 	if err != nil {
 		return fmt.Errorf("error generating bootstrap kubeconfig: %w", err)
 	}
@@ -119,11 +455,34 @@ func Run(opts Options) error {
 		}
 	}
 
-	apiCfgData, err := clientcmd.Write(*apiCfg)
+	if opts.Printer == nil {
+		apiCfgData, err := clientcmd.Write(*apiCfg)
+		if err != nil {
+			return err
+		}
+
+		_, _ = io.Copy(opts.Out, bytes.NewReader(apiCfgData))
+		return nil
+	}
+
+	secret, err := bootstrapkubeconfig.ToSecret(apiCfg, opts.SecretName, opts.SecretNamespace, opts.SecretKey)
 	if err != nil {
 		return err
 	}
 
-	_, _ = io.Copy(opts.Out, bytes.NewReader(apiCfgData))
+	if opts.Apply && opts.DryRun != cmdutil.DryRunClient {
+		c, err := opts.NewApplyClient()
+		if err != nil {
+			return fmt.Errorf("error creating client: %w", err)
+		}
+
+		if err := apply.Object(ctx, c, secret, opts.DryRun); err != nil {
+			return fmt.Errorf("error applying bootstrap kubeconfig secret: %w", err)
+		}
+	}
+
+	if err := opts.Printer.PrintObj(secret, opts.Out); err != nil {
+		return fmt.Errorf("error printing object: %w", err)
+	}
 	return nil
 }
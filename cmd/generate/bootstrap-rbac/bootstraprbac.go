@@ -0,0 +1,173 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstraprbac
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ironcore-dev/kubectl-ironcore/bootstraprbac"
+	"github.com/ironcore-dev/kubectl-ironcore/bootstraptoken"
+	"github.com/ironcore-dev/kubectl-ironcore/utils/apply"
+	"github.com/ironcore-dev/kubectl-ironcore/utils/kubeconfig"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/kubernetes/scheme"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type Flags struct {
+	Type             bootstraptoken.Type
+	ClusterInfo      bool
+	PoolScoped       bool
+	Apply            bool
+	RESTClientGetter genericclioptions.RESTClientGetter
+	PrintFlags       *genericclioptions.PrintFlags
+	genericclioptions.IOStreams
+}
+
+func NewFlags(restClientGetter genericclioptions.RESTClientGetter, streams genericclioptions.IOStreams) *Flags {
+	printFlags := genericclioptions.NewPrintFlags("").
+		WithTypeSetter(scheme.Scheme)
+
+	return &Flags{
+		RESTClientGetter: restClientGetter,
+		PrintFlags:       printFlags,
+		IOStreams:        streams,
+	}
+}
+
+func (f *Flags) AddFlags(cmd *cobra.Command) {
+	cmdutil.AddDryRunFlag(cmd)
+	bootstraptoken.AddTypeFlag(cmd, &f.Type, "Type to generate the RBAC for. If unset, the RBAC for all types is generated.")
+	cmd.Flags().BoolVar(&f.ClusterInfo, "cluster-info", false, "Whether to also generate the RBAC allowing anonymous access to the cluster-info ConfigMap used for token discovery.")
+	cmd.Flags().BoolVar(&f.PoolScoped, "pool-scoped", false, "Whether to omit the auto-approval permission so certificate signing requests have to be approved by 'certificate approve', which enforces the pool scope of tokens.")
+	cmd.Flags().BoolVar(&f.Apply, "apply", false, "Whether to server-side apply the RBAC to the cluster of the kubeconfig context.")
+	f.PrintFlags.AddFlags(cmd)
+}
+
+func (f *Flags) ToOptions(cmd *cobra.Command) (*Options, error) {
+	types := sets.List(bootstraptoken.AvailableTypes)
+	if f.Type != "" {
+		if !bootstraptoken.AvailableTypes.Has(f.Type) {
+			return nil, fmt.Errorf("unknown type %q", f.Type)
+		}
+		types = []bootstraptoken.Type{f.Type}
+	}
+
+	dryRunStrategy, err := cmdutil.GetDryRunStrategy(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.Apply {
+		f.PrintFlags.NamePrintFlags.Operation = "serverside-applied"
+	} else if *f.PrintFlags.OutputFormat == "" {
+		*f.PrintFlags.OutputFormat = "yaml"
+	}
+	cmdutil.PrintFlagsWithDryRunStrategy(f.PrintFlags, dryRunStrategy)
+	printer, err := f.PrintFlags.ToPrinter()
+	if err != nil {
+		return nil, err
+	}
+
+	newClient := func() (client.Client, error) {
+		return kubeconfig.NewClient(f.RESTClientGetter, "")
+	}
+
+	return &Options{
+		Types:       types,
+		ClusterInfo: f.ClusterInfo,
+		PoolScoped:  f.PoolScoped,
+		Apply:       f.Apply,
+		DryRun:      dryRunStrategy,
+		Printer:     printer,
+		NewClient:   newClient,
+		IOStreams:   f.IOStreams,
+	}, nil
+}
+
+type Options struct {
+	Types       []bootstraptoken.Type
+	ClusterInfo bool
+	PoolScoped  bool
+
+	// Apply instructs to server-side apply the RBAC using a client created by NewClient.
+	Apply  bool
+	DryRun cmdutil.DryRunStrategy
+
+	Printer printers.ResourcePrinter
+
+	NewClient func() (client.Client, error)
+	genericclioptions.IOStreams
+}
+
+func Command(restClientGetter genericclioptions.RESTClientGetter, streams genericclioptions.IOStreams) *cobra.Command {
+	flags := NewFlags(restClientGetter, streams)
+
+	cmd := &cobra.Command{
+		Use:   "bootstrap-rbac",
+		Short: "Generate the RBAC allowing bootstrap tokens to register their components.",
+		Long: `Generate the RBAC allowing bootstrap tokens to register their components.
+
+For each token type, a ClusterRole and ClusterRoleBinding are generated that allow the groups of the
+type to create certificate signing requests and to have them auto-approved for the component of the type.
+
+Auto-approval cannot restrict the pool name a token registers. With --pool-scoped, the auto-approval
+permission is omitted and certificate signing requests have to be approved with 'kubectl ironcore
+certificate approve' (e.g. running with --watch), which only approves the pool a token created with
+'create token --pool-name' is scoped to.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts, err := flags.ToOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			return Run(cmd.Context(), *opts)
+		},
+	}
+
+	flags.AddFlags(cmd)
+
+	return cmd
+}
+
+func Run(ctx context.Context, opts Options) error {
+	var objs []client.Object
+	for _, typ := range opts.Types {
+		typObjs, err := bootstraprbac.ForType(typ, bootstraprbac.WithPoolScoped(opts.PoolScoped))
+		if err != nil {
+			return err
+		}
+		objs = append(objs, typObjs...)
+	}
+	if opts.ClusterInfo {
+		objs = append(objs, bootstraprbac.ClusterInfo()...)
+	}
+
+	var c client.Client
+	if opts.Apply && opts.DryRun != cmdutil.DryRunClient {
+		var err error
+		c, err = opts.NewClient()
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, obj := range objs {
+		if c != nil {
+			if err := apply.Object(ctx, c, obj, opts.DryRun); err != nil {
+				return fmt.Errorf("error applying %s %s: %w", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetName(), err)
+			}
+		}
+
+		if err := opts.Printer.PrintObj(obj, opts.Out); err != nil {
+			return fmt.Errorf("error printing object: %w", err)
+		}
+	}
+	return nil
+}
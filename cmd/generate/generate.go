@@ -5,26 +5,22 @@ package generate
 
 import (
 	bootstrapkubeconfig "github.com/ironcore-dev/kubectl-ironcore/cmd/generate/bootstrap-kubeconfig"
+	bootstraprbac "github.com/ironcore-dev/kubectl-ironcore/cmd/generate/bootstrap-rbac"
+	"github.com/ironcore-dev/kubectl-ironcore/cmd/generate/poollet"
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
-	"k8s.io/client-go/tools/clientcmd"
 )
 
-func Command(pathOptions *clientcmd.PathOptions, streams genericclioptions.IOStreams) *cobra.Command {
-	if len(pathOptions.ExplicitFileFlag) == 0 {
-		pathOptions.ExplicitFileFlag = clientcmd.RecommendedConfigPathFlag
-	}
-
+func Command(restClientGetter genericclioptions.RESTClientGetter, streams genericclioptions.IOStreams) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "generate",
 		Short: "Generate artifacts / configuration commands.",
 	}
 
-	// file paths are common to all sub commands
-	cmd.PersistentFlags().StringVar(&pathOptions.LoadingRules.ExplicitPath, pathOptions.ExplicitFileFlag, pathOptions.LoadingRules.ExplicitPath, "use a particular kubeconfig file")
-
 	cmd.AddCommand(
-		bootstrapkubeconfig.Command(pathOptions, streams),
+		bootstrapkubeconfig.Command(restClientGetter, streams),
+		bootstraprbac.Command(restClientGetter, streams),
+		poollet.Command(restClientGetter, streams),
 	)
 
 	return cmd
@@ -0,0 +1,242 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package poollet
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ironcore-dev/kubectl-ironcore/bootstraptoken"
+	"github.com/ironcore-dev/kubectl-ironcore/poollet"
+	"github.com/ironcore-dev/kubectl-ironcore/utils/apply"
+	utilbootstraptoken "github.com/ironcore-dev/kubectl-ironcore/utils/bootstraptoken"
+	"github.com/ironcore-dev/kubectl-ironcore/utils/kubeconfig"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const typeFlagName = "type"
+
+type Flags struct {
+	Type             bootstraptoken.Type
+	PoolName         string
+	Name             string
+	Image            string
+	Command          []string
+	Args             []string
+	TemplateFile     string
+	TokenExpiry      bootstraptoken.ExpiryFlags
+	TokenDescription string
+	RESTClientGetter genericclioptions.RESTClientGetter
+	genericclioptions.IOStreams
+}
+
+func NewFlags(restClientGetter genericclioptions.RESTClientGetter, streams genericclioptions.IOStreams) *Flags {
+	return &Flags{
+		RESTClientGetter: restClientGetter,
+		IOStreams:        streams,
+	}
+}
+
+func (f *Flags) AddFlags(cmd *cobra.Command) {
+	cmdutil.AddDryRunFlag(cmd)
+	cmd.Flags().StringVar((*string)(&f.Type), typeFlagName, "", fmt.Sprintf("Type of the poollet. Available types: %v", sets.List(bootstraptoken.AvailableTypes)))
+	_ = cmd.RegisterFlagCompletionFunc(typeFlagName, bootstraptoken.CompleteTypes)
+	cmd.Flags().StringVar(&f.PoolName, "pool-name", "", "Name of the pool the poollet registers.")
+	cmd.Flags().StringVar(&f.Name, "name", "", "Name of the poollet resources. If unset, it is derived from the type and pool name.")
+	cmd.Flags().StringVar(&f.Image, "image", "", "Container image of the poollet. If unset, the default image of the type tagged with the plugin release version is used.")
+	cmd.Flags().StringSliceVar(&f.Command, "command", nil, "Command of the poollet container. If unset, the image entrypoint is used.")
+	cmd.Flags().StringSliceVar(&f.Args, "args", nil, "Arguments of the poollet container. If unset, the bootstrap kubeconfig and pool name arguments of the type are used.")
+	cmd.Flags().StringVar(&f.TemplateFile, "template-file", "", "File containing a Go template to render the poollet manifests with. If unset, a default template is used.")
+	f.TokenExpiry.AddFlags(cmd)
+	cmd.Flags().StringVar(&f.TokenDescription, "token-description", "", "Token description to use to generate. If unset, a description mentioning the pool is used.")
+}
+
+func (f *Flags) ToOptions(cmd *cobra.Command) (*Options, error) {
+	if f.Type == "" {
+		return nil, fmt.Errorf("must specify --%s", typeFlagName)
+	}
+	if !bootstraptoken.AvailableTypes.Has(f.Type) {
+		return nil, fmt.Errorf("unknown type %q", f.Type)
+	}
+	if f.PoolName == "" {
+		return nil, fmt.Errorf("must specify --pool-name")
+	}
+	if errs := validation.IsDNS1123Subdomain(f.PoolName); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid pool name %q: %s", f.PoolName, strings.Join(errs, ", "))
+	}
+
+	cfg := poollet.DefaultConfig(f.Type)
+	image := f.Image
+	if image == "" {
+		var err error
+		image, err = poollet.DefaultImage(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("type %s has no default poollet image, must specify --image: %w", f.Type, err)
+		}
+	}
+	command := f.Command
+	if command == nil {
+		command = cfg.Command
+	}
+	args := f.Args
+	if args == nil {
+		args = poollet.DefaultArgs(cfg, f.PoolName)
+	}
+
+	name := f.Name
+	if name == "" {
+		name = fmt.Sprintf("%s-%s", strings.ToLower(string(f.Type)), f.PoolName)
+	}
+
+	templateText := poollet.DefaultTemplate
+	if f.TemplateFile != "" {
+		data, err := os.ReadFile(f.TemplateFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading template file: %w", err)
+		}
+		templateText = string(data)
+	}
+
+	description := f.TokenDescription
+	if description == "" {
+		description = fmt.Sprintf("Bootstrap token for %s %s.", f.Type, f.PoolName)
+	}
+	template, err := f.TokenExpiry.Apply(cmd, &utilbootstraptoken.BootstrapToken{Description: description})
+	if err != nil {
+		return nil, err
+	}
+	if err := bootstraptoken.AddTypeFields(template, f.Type); err != nil {
+		return nil, err
+	}
+	if err := bootstraptoken.AddPoolGroup(template, f.Type, f.PoolName); err != nil {
+		return nil, err
+	}
+
+	dryRunStrategy, err := cmdutil.GetDryRunStrategy(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	contextName, _ := cmd.Flags().GetString(clientcmd.FlagContext)
+	startingCfg, err := kubeconfig.StartingConfig(f.RESTClientGetter, contextName)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace, err := kubeconfig.Namespace(f.RESTClientGetter, "")
+	if err != nil {
+		return nil, err
+	}
+
+	newClient := func() (client.Client, error) {
+		return kubeconfig.NewClient(f.RESTClientGetter, "")
+	}
+
+	return &Options{
+		DryRun:       dryRunStrategy,
+		Template:     *template,
+		TemplateText: templateText,
+		Data: poollet.Data{
+			Type:                   f.Type,
+			PoolName:               f.PoolName,
+			Name:                   name,
+			Namespace:              namespace,
+			SecretName:             name + "-bootstrap-kubeconfig",
+			SecretKey:              poollet.BootstrapKubeconfigKey,
+			BootstrapKubeconfigDir: poollet.BootstrapKubeconfigDir,
+			Kind:                   cfg.Kind,
+			Image:                  image,
+			Command:                command,
+			Args:                   args,
+		},
+		StartingCfg: startingCfg,
+		NewClient:   newClient,
+		IOStreams:   f.IOStreams,
+	}, nil
+}
+
+type Options struct {
+	DryRun cmdutil.DryRunStrategy
+
+	// Template is the template for the bootstrap token of the poollet.
+	Template utilbootstraptoken.BootstrapToken
+
+	// TemplateText is the Go template to render Data with.
+	// Data is completed with the bootstrap token and kubeconfig before rendering.
+	TemplateText string
+	Data         poollet.Data
+
+	StartingCfg *clientcmdapi.Config
+
+	NewClient func() (client.Client, error)
+	genericclioptions.IOStreams
+}
+
+func Command(restClientGetter genericclioptions.RESTClientGetter, streams genericclioptions.IOStreams) *cobra.Command {
+	flags := NewFlags(restClientGetter, streams)
+
+	cmd := &cobra.Command{
+		Use:   "poollet",
+		Short: "Generate the manifests to deploy a poollet registering with a new bootstrap token.",
+		Long: `Generate the manifests to deploy a poollet registering with a new bootstrap token.
+
+The bootstrap token is scoped to the pool of the poollet (see 'create token --pool-name'),
+so it can only be used to register that pool.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts, err := flags.ToOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			return Run(cmd.Context(), *opts)
+		},
+	}
+
+	flags.AddFlags(cmd)
+
+	return cmd
+}
+
+func Run(ctx context.Context, opts Options) error {
+	t, err := utilbootstraptoken.Generate(&opts.Template)
+	if err != nil {
+		return fmt.Errorf("error generating token: %w", err)
+	}
+
+	secret := utilbootstraptoken.ToSecret(t)
+	if opts.DryRun != cmdutil.DryRunClient {
+		c, err := opts.NewClient()
+		if err != nil {
+			return err
+		}
+		if err := apply.Object(ctx, c, secret, opts.DryRun); err != nil {
+			return err
+		}
+	}
+	_, _ = fmt.Fprintf(opts.ErrOut, "Created bootstrap token %s%s\n", t.ID, apply.DryRunSuffix(opts.DryRun))
+
+	apiCfg, err := kubeconfig.GenerateBootstrap(opts.StartingCfg, t)
+	if err != nil {
+		return err
+	}
+
+	apiCfgData, err := clientcmd.Write(*apiCfg)
+	if err != nil {
+		return err
+	}
+
+	data := opts.Data
+	data.TokenID = t.ID
+	data.BootstrapKubeconfig = string(apiCfgData)
+	return poollet.Render(opts.Out, opts.TemplateText, &data)
+}
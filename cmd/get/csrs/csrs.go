@@ -0,0 +1,209 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package csrs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ironcore-dev/kubectl-ironcore/bootstrapcsr"
+	"github.com/spf13/cobra"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/kubernetes/scheme"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	wideOutputFormat = "wide"
+
+	none = "<none>"
+)
+
+type Flags struct {
+	Factory    cmdutil.Factory
+	PrintFlags *genericclioptions.PrintFlags
+	genericclioptions.IOStreams
+}
+
+func NewFlags(f cmdutil.Factory, streams genericclioptions.IOStreams) *Flags {
+	printFlags := genericclioptions.NewPrintFlags("").
+		WithTypeSetter(scheme.Scheme)
+
+	return &Flags{
+		Factory:    f,
+		PrintFlags: printFlags,
+		IOStreams:  streams,
+	}
+}
+
+func (f *Flags) AddFlags(cmd *cobra.Command) {
+	f.PrintFlags.AddFlags(cmd)
+
+	outputFlag := cmd.Flags().Lookup("output")
+	outputFlag.Usage = fmt.Sprintf("Output format. One of: (%s).", strings.Join(append(f.PrintFlags.AllowedFormats(), wideOutputFormat), ", "))
+}
+
+func (f *Flags) ToOptions() (*Options, error) {
+	var (
+		printer       printers.ResourcePrinter
+		humanReadable bool
+	)
+	if outputFormat := *f.PrintFlags.OutputFormat; outputFormat == "" || outputFormat == wideOutputFormat {
+		printer = printers.NewTablePrinter(printers.PrintOptions{
+			Wide: outputFormat == wideOutputFormat,
+		})
+		humanReadable = true
+	} else {
+		var err error
+		printer, err = f.PrintFlags.ToPrinter()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cfg, err := f.Factory.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	newClient := func() (client.Client, error) {
+		return client.New(cfg, client.Options{})
+	}
+
+	return &Options{
+		Printer:       printer,
+		HumanReadable: humanReadable,
+		NewClient:     newClient,
+		IOStreams:     f.IOStreams,
+	}, nil
+}
+
+type Options struct {
+	Printer       printers.ResourcePrinter
+	HumanReadable bool
+	NewClient     func() (client.Client, error)
+	genericclioptions.IOStreams
+}
+
+func Command(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
+	flags := NewFlags(f, streams)
+
+	cmd := &cobra.Command{
+		Use:     "csrs",
+		Aliases: []string{"csr"},
+		Short:   "List the pending certificate signing requests of users of ironcore bootstrap token types.",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts, err := flags.ToOptions()
+			if err != nil {
+				return err
+			}
+
+			return Run(cmd.Context(), *opts)
+		},
+	}
+
+	flags.AddFlags(cmd)
+
+	return cmd
+}
+
+func Run(ctx context.Context, opts Options) error {
+	c, err := opts.NewClient()
+	if err != nil {
+		return err
+	}
+
+	csrs, err := bootstrapcsr.ListPending(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	var obj runtime.Object
+	if opts.HumanReadable {
+		obj = toTable(csrs, time.Now())
+	} else {
+		obj, err = toList(csrs)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := opts.Printer.PrintObj(obj, opts.Out); err != nil {
+		return fmt.Errorf("error printing object: %w", err)
+	}
+	return nil
+}
+
+func toList(csrs []certificatesv1.CertificateSigningRequest) (*unstructured.UnstructuredList, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetAPIVersion("v1")
+	list.SetKind("List")
+	for i := range csrs {
+		csr := csrs[i].DeepCopy()
+		csr.SetGroupVersionKind(certificatesv1.SchemeGroupVersion.WithKind("CertificateSigningRequest"))
+
+		obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(csr)
+		if err != nil {
+			return nil, fmt.Errorf("error converting certificate signing request %s to unstructured: %w", csr.Name, err)
+		}
+
+		list.Items = append(list.Items, unstructured.Unstructured{Object: obj})
+	}
+	return list, nil
+}
+
+var columnDefinitions = []metav1.TableColumnDefinition{
+	{Name: "Name", Type: "string", Format: "name"},
+	{Name: "Age", Type: "string"},
+	{Name: "Requestor", Type: "string"},
+	{Name: "Type", Type: "string"},
+	{Name: "Pool", Type: "string"},
+	{Name: "Valid", Type: "string"},
+	{Name: "Reason", Type: "string", Priority: 1},
+}
+
+func toTable(csrs []certificatesv1.CertificateSigningRequest, now time.Time) *metav1.Table {
+	table := &metav1.Table{
+		ColumnDefinitions: columnDefinitions,
+	}
+	for i := range csrs {
+		csr := &csrs[i]
+		var (
+			typ, pool = none, none
+			valid     = "true"
+			reason    = none
+		)
+		if req, err := bootstrapcsr.Validate(csr); err != nil {
+			valid = "false"
+			reason = err.Error()
+		} else {
+			typ = string(req.Type)
+			pool = req.PoolName
+		}
+
+		table.Rows = append(table.Rows, metav1.TableRow{
+			Cells: []interface{}{
+				csr.Name,
+				duration.HumanDuration(now.Sub(csr.CreationTimestamp.Time)),
+				csr.Spec.Username,
+				typ,
+				pool,
+				valid,
+				reason,
+			},
+			Object: runtime.RawExtension{Object: csr},
+		})
+	}
+	return table
+}
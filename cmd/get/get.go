@@ -0,0 +1,26 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package get
+
+import (
+	"github.com/ironcore-dev/kubectl-ironcore/cmd/get/csrs"
+	"github.com/ironcore-dev/kubectl-ironcore/cmd/get/tokens"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+func Command(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "get",
+		Short: "Display items in a cluster.",
+	}
+
+	cmd.AddCommand(
+		tokens.Command(f, streams),
+		csrs.Command(f, streams),
+	)
+
+	return cmd
+}
@@ -0,0 +1,284 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package tokens
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ironcore-dev/kubectl-ironcore/bootstraptoken"
+	utilbootstraptoken "github.com/ironcore-dev/kubectl-ironcore/utils/bootstraptoken"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/kubernetes/scheme"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	wideOutputFormat = "wide"
+
+	none = "<none>"
+
+	defaultExpiryWarningThreshold = time.Hour
+)
+
+type Flags struct {
+	Factory                cmdutil.Factory
+	ExpiryWarningThreshold time.Duration
+	PrintFlags             *genericclioptions.PrintFlags
+	genericclioptions.IOStreams
+}
+
+func NewFlags(f cmdutil.Factory, streams genericclioptions.IOStreams) *Flags {
+	printFlags := genericclioptions.NewPrintFlags("").
+		WithTypeSetter(scheme.Scheme)
+
+	return &Flags{
+		Factory:    f,
+		PrintFlags: printFlags,
+		IOStreams:  streams,
+	}
+}
+
+func (f *Flags) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().DurationVar(&f.ExpiryWarningThreshold, "expiry-warning-threshold", defaultExpiryWarningThreshold, "Warn about tokens expiring within the given duration. 0 disables the warnings.")
+	f.PrintFlags.AddFlags(cmd)
+
+	outputFlag := cmd.Flags().Lookup("output")
+	outputFlag.Usage = fmt.Sprintf("Output format. One of: (%s).", strings.Join(append(f.PrintFlags.AllowedFormats(), wideOutputFormat), ", "))
+}
+
+func (f *Flags) ToOptions() (*Options, error) {
+	var (
+		printer       printers.ResourcePrinter
+		humanReadable bool
+	)
+	if outputFormat := *f.PrintFlags.OutputFormat; outputFormat == "" || outputFormat == wideOutputFormat {
+		printer = printers.NewTablePrinter(printers.PrintOptions{
+			Wide: outputFormat == wideOutputFormat,
+		})
+		humanReadable = true
+	} else {
+		var err error
+		printer, err = f.PrintFlags.ToPrinter()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cfg, err := f.Factory.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	newClient := func() (client.Client, error) {
+		return client.New(cfg, client.Options{})
+	}
+
+	return &Options{
+		Printer:                printer,
+		HumanReadable:          humanReadable,
+		ExpiryWarningThreshold: f.ExpiryWarningThreshold,
+		NewClient:              newClient,
+		IOStreams:              f.IOStreams,
+	}, nil
+}
+
+type Options struct {
+	Printer       printers.ResourcePrinter
+	HumanReadable bool
+	// ExpiryWarningThreshold is the duration within which expiring tokens are warned about on ErrOut.
+	ExpiryWarningThreshold time.Duration
+	NewClient              func() (client.Client, error)
+	genericclioptions.IOStreams
+}
+
+func Command(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
+	flags := NewFlags(f, streams)
+
+	cmd := &cobra.Command{
+		Use:     "tokens",
+		Aliases: []string{"token"},
+		Short:   "List the bootstrap tokens of a cluster.",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts, err := flags.ToOptions()
+			if err != nil {
+				return err
+			}
+
+			return Run(cmd.Context(), *opts)
+		},
+	}
+
+	flags.AddFlags(cmd)
+
+	return cmd
+}
+
+type item struct {
+	Secret *corev1.Secret
+	Token  *utilbootstraptoken.BootstrapToken
+}
+
+func Run(ctx context.Context, opts Options) error {
+	c, err := opts.NewClient()
+	if err != nil {
+		return err
+	}
+
+	secrets, err := utilbootstraptoken.ListSecrets(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	var items []item
+	for i := range secrets {
+		secret := &secrets[i]
+		token, err := utilbootstraptoken.FromSecret(secret)
+		if err != nil {
+			_, _ = fmt.Fprintf(opts.ErrOut, "Skipping secret %s/%s: %v\n", secret.Namespace, secret.Name, err)
+			continue
+		}
+
+		items = append(items, item{Secret: secret, Token: token})
+	}
+
+	now := time.Now()
+	if opts.ExpiryWarningThreshold > 0 {
+		warnExpiring(opts.ErrOut, items, opts.ExpiryWarningThreshold, now)
+	}
+
+	var obj runtime.Object
+	if opts.HumanReadable {
+		obj = toTable(items, now)
+	} else {
+		obj, err = toList(items)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := opts.Printer.PrintObj(obj, opts.Out); err != nil {
+		return fmt.Errorf("error printing object: %w", err)
+	}
+	return nil
+}
+
+// warnExpiring warns about the unexpired tokens expiring within the given threshold.
+func warnExpiring(w io.Writer, items []item, threshold time.Duration, now time.Time) {
+	for _, it := range items {
+		expires := it.Token.Expires
+		if expires == nil || !expires.After(now) {
+			continue
+		}
+		if ttl := expires.Sub(now); ttl <= threshold {
+			_, _ = fmt.Fprintf(w, "Warning: bootstrap token %s expires in %s\n", it.Token.ID, duration.HumanDuration(ttl))
+		}
+	}
+}
+
+func toList(items []item) (*unstructured.UnstructuredList, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetAPIVersion("v1")
+	list.SetKind("List")
+	for _, it := range items {
+		secret := it.Secret.DeepCopy()
+		secret.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Secret"))
+
+		obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(secret)
+		if err != nil {
+			return nil, fmt.Errorf("error converting secret %s to unstructured: %w", secret.Name, err)
+		}
+
+		list.Items = append(list.Items, unstructured.Unstructured{Object: obj})
+	}
+	return list, nil
+}
+
+var columnDefinitions = []metav1.TableColumnDefinition{
+	{Name: "ID", Type: "string", Format: "name"},
+	{Name: "Type", Type: "string"},
+	{Name: "TTL", Type: "string"},
+	{Name: "Expires", Type: "string"},
+	{Name: "Usages", Type: "string"},
+	{Name: "Description", Type: "string"},
+	{Name: "Groups", Type: "string", Priority: 1},
+}
+
+func toTable(items []item, now time.Time) *metav1.Table {
+	table := &metav1.Table{
+		ColumnDefinitions: columnDefinitions,
+	}
+	for _, it := range items {
+		token := it.Token
+		table.Rows = append(table.Rows, metav1.TableRow{
+			Cells: []interface{}{
+				token.ID,
+				formatTypes(token),
+				formatTTL(token.Expires, now),
+				formatExpires(token.Expires),
+				formatList(token.Usages),
+				formatString(token.Description),
+				formatList(token.Groups),
+			},
+			Object: runtime.RawExtension{Object: it.Secret},
+		})
+	}
+	return table
+}
+
+func formatTypes(token *utilbootstraptoken.BootstrapToken) string {
+	types := bootstraptoken.TypesOf(token)
+	if len(types) == 0 {
+		return none
+	}
+
+	res := make([]string, 0, len(types))
+	for _, typ := range types {
+		res = append(res, string(typ))
+	}
+	return strings.Join(res, ",")
+}
+
+func formatTTL(expires *time.Time, now time.Time) string {
+	if expires == nil {
+		return "<forever>"
+	}
+	if !expires.After(now) {
+		return "<expired>"
+	}
+	return duration.HumanDuration(expires.Sub(now))
+}
+
+func formatExpires(expires *time.Time) string {
+	if expires == nil {
+		return "<never>"
+	}
+	return expires.UTC().Format(time.RFC3339)
+}
+
+func formatList(items []string) string {
+	if len(items) == 0 {
+		return none
+	}
+	return strings.Join(items, ",")
+}
+
+func formatString(s string) string {
+	if s == "" {
+		return none
+	}
+	return s
+}
@@ -6,14 +6,21 @@ package kubectlironcore
 import (
 	"os"
 
+	"github.com/ironcore-dev/kubectl-ironcore/bootstraptoken"
+	"github.com/ironcore-dev/kubectl-ironcore/cmd/bootstrap"
+	"github.com/ironcore-dev/kubectl-ironcore/cmd/certificate"
+	"github.com/ironcore-dev/kubectl-ironcore/cmd/console"
 	"github.com/ironcore-dev/kubectl-ironcore/cmd/create"
+	"github.com/ironcore-dev/kubectl-ironcore/cmd/delete"
+	"github.com/ironcore-dev/kubectl-ironcore/cmd/describe"
 	"github.com/ironcore-dev/kubectl-ironcore/cmd/exec"
 	"github.com/ironcore-dev/kubectl-ironcore/cmd/generate"
+	"github.com/ironcore-dev/kubectl-ironcore/cmd/get"
 	"github.com/ironcore-dev/kubectl-ironcore/cmd/options"
+	"github.com/ironcore-dev/kubectl-ironcore/cmd/rotate"
 	"github.com/ironcore-dev/kubectl-ironcore/cmd/version"
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
-	"k8s.io/client-go/tools/clientcmd"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"k8s.io/kubectl/pkg/util/templates"
 )
@@ -30,7 +37,8 @@ func DefaultCommand() *cobra.Command {
 
 func Command(opts Options) *cobra.Command {
 	var (
-		configFlags = genericclioptions.NewConfigFlags(true)
+		configFlags          = genericclioptions.NewConfigFlags(true)
+		overrideBuiltinTypes bool
 	)
 
 	cmd := &cobra.Command{
@@ -40,15 +48,24 @@ func Command(opts Options) *cobra.Command {
 	}
 
 	configFlags.AddFlags(cmd.PersistentFlags())
+	// The flag is evaluated before the command line is parsed when loading the token types, see main.
+	bootstraptoken.AddOverrideBuiltinTypesFlag(cmd.PersistentFlags(), &overrideBuiltinTypes)
 
 	f := cmdutil.NewFactory(configFlags)
 
 	templates.ActsAsRootCommand(cmd, []string{"options"})
 
 	cmd.AddCommand(
-		exec.Command(configFlags),
+		exec.Command(configFlags, opts.IOStreams),
+		console.Command(opts.IOStreams),
 		create.Command(f, opts.IOStreams),
-		generate.Command(clientcmd.NewDefaultPathOptions(), opts.IOStreams),
+		get.Command(f, opts.IOStreams),
+		describe.Command(f, opts.IOStreams),
+		delete.Command(f, opts.IOStreams),
+		rotate.Command(f, opts.IOStreams),
+		generate.Command(configFlags, opts.IOStreams),
+		bootstrap.Command(f, opts.IOStreams),
+		certificate.Command(f, opts.IOStreams),
 		options.Command(opts.IOStreams.Out),
 		version.Command(opts.IOStreams.Out),
 	)
@@ -0,0 +1,281 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package token
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ironcore-dev/kubectl-ironcore/bootstraptoken"
+	"github.com/ironcore-dev/kubectl-ironcore/utils/apply"
+	utilbootstraptoken "github.com/ironcore-dev/kubectl-ironcore/utils/bootstraptoken"
+	"github.com/ironcore-dev/kubectl-ironcore/utils/kubeconfig"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type Flags struct {
+	Factory     cmdutil.Factory
+	Type        bootstraptoken.Type
+	PoolName    string
+	GracePeriod time.Duration
+	Expiry      bootstraptoken.ExpiryFlags
+	genericclioptions.IOStreams
+}
+
+func NewFlags(f cmdutil.Factory, streams genericclioptions.IOStreams) *Flags {
+	return &Flags{
+		Factory:   f,
+		IOStreams: streams,
+	}
+}
+
+func (f *Flags) AddFlags(cmd *cobra.Command) {
+	cmdutil.AddDryRunFlag(cmd)
+	bootstraptoken.AddTypeFlag(cmd, &f.Type, "Type of the tokens to rotate.")
+	cmd.Flags().StringVar(&f.PoolName, "pool-name", "", "Only rotate the tokens scoped to the pool of the given name.")
+	cmd.Flags().DurationVar(&f.GracePeriod, "grace-period", 0, "Duration to wait before deleting the old tokens. If unset, old tokens are deleted immediately.")
+	f.Expiry.AddFlags(cmd)
+}
+
+func (f *Flags) ToOptions(cmd *cobra.Command) (*Options, error) {
+	if f.Type == "" {
+		return nil, fmt.Errorf("must specify --token-type")
+	}
+	if !bootstraptoken.AvailableTypes.Has(f.Type) {
+		return nil, fmt.Errorf("unknown type %q", f.Type)
+	}
+	if f.PoolName != "" {
+		if _, err := bootstraptoken.PoolGroup(f.Type, f.PoolName); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := f.Expiry.ExpiryPolicy.Complete(cmd); err != nil {
+		return nil, err
+	}
+
+	dryRunStrategy, err := cmdutil.GetDryRunStrategy(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	startingCfg, err := f.Factory.ToRawKubeConfigLoader().RawConfig()
+	if err != nil {
+		return nil, err
+	}
+	if contextName, _ := cmd.Flags().GetString(clientcmd.FlagContext); contextName != "" {
+		startingCfg.CurrentContext = contextName
+	}
+
+	cfg, err := f.Factory.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	newClient := func() (client.Client, error) {
+		return client.New(cfg, client.Options{})
+	}
+
+	return &Options{
+		Type:        f.Type,
+		PoolName:    f.PoolName,
+		GracePeriod: f.GracePeriod,
+		Expiry:      f.Expiry,
+		DryRun:      dryRunStrategy,
+		StartingCfg: &startingCfg,
+		NewClient:   newClient,
+		IOStreams:   f.IOStreams,
+	}, nil
+}
+
+type Options struct {
+	Type bootstraptoken.Type
+	// PoolName restricts the rotated tokens to the ones scoped to the pool of the name.
+	PoolName    string
+	GracePeriod time.Duration
+	// Expiry overrides the expiration inherited from the rotated tokens and checks it against its policy.
+	// Its policy has to be completed.
+	Expiry bootstraptoken.ExpiryFlags
+
+	DryRun      cmdutil.DryRunStrategy
+	StartingCfg *clientcmdapi.Config
+	NewClient   func() (client.Client, error)
+	genericclioptions.IOStreams
+}
+
+func Command(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
+	flags := NewFlags(f, streams)
+
+	cmd := &cobra.Command{
+		Use:   "token",
+		Short: "Replace the bootstrap tokens of a type with a fresh one and print its bootstrap kubeconfig.",
+		Long: `Replace the bootstrap tokens of a type with a fresh one and print its bootstrap kubeconfig.
+
+The new token inherits the description, usages, groups and TTL of the newest of the replaced tokens.
+Use --token-ttl or --token-expires to set a different expiration. The expiration is checked against
+--max-token-ttl and --allow-no-expiry.
+
+All replaced tokens have to have the same groups. If tokens are scoped to different pools, use
+--pool-name to rotate the tokens of a single pool.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts, err := flags.ToOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			return Run(cmd.Context(), *opts)
+		},
+	}
+
+	flags.AddFlags(cmd)
+
+	return cmd
+}
+
+func Run(ctx context.Context, opts Options) error {
+	c, err := opts.NewClient()
+	if err != nil {
+		return err
+	}
+
+	oldSecrets, template, err := findTokens(ctx, c, opts)
+	if err != nil {
+		return err
+	}
+
+	template, err = opts.Expiry.WithExpiry(template)
+	if err != nil {
+		return err
+	}
+
+	t, err := utilbootstraptoken.Generate(template)
+	if err != nil {
+		return fmt.Errorf("error generating token: %w", err)
+	}
+
+	secret := utilbootstraptoken.ToSecret(t)
+	if opts.DryRun != cmdutil.DryRunClient {
+		if err := apply.Object(ctx, c, secret, opts.DryRun); err != nil {
+			return err
+		}
+	}
+	_, _ = fmt.Fprintf(opts.ErrOut, "Created bootstrap token %s%s\n", t.ID, apply.DryRunSuffix(opts.DryRun))
+
+	apiCfg, err := kubeconfig.GenerateBootstrap(opts.StartingCfg, t)
+	if err != nil {
+		return err
+	}
+
+	apiCfgData, err := clientcmd.Write(*apiCfg)
+	if err != nil {
+		return err
+	}
+	_, _ = opts.Out.Write(apiCfgData)
+
+	if opts.GracePeriod > 0 {
+		_, _ = fmt.Fprintf(opts.ErrOut, "Waiting %s before deleting %d old bootstrap token(s)\n", opts.GracePeriod, len(oldSecrets))
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("aborted before deleting old bootstrap tokens: %w", ctx.Err())
+		case <-time.After(opts.GracePeriod):
+		}
+	}
+
+	var deleteOpts []client.DeleteOption
+	if opts.DryRun == cmdutil.DryRunServer {
+		deleteOpts = append(deleteOpts, client.DryRunAll)
+	}
+
+	for _, oldSecret := range oldSecrets {
+		if opts.DryRun != cmdutil.DryRunClient {
+			if err := c.Delete(ctx, oldSecret, deleteOpts...); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("error deleting old bootstrap token secret %s: %w", oldSecret.Name, err)
+			}
+		}
+		_, _ = fmt.Fprintf(opts.ErrOut, "Deleted bootstrap token secret %s%s\n", oldSecret.Name, apply.DryRunSuffix(opts.DryRun))
+	}
+	return nil
+}
+
+// findTokens finds all secrets of tokens of the given type (and pool, if specified) and derives a template
+// from the newest of them. All found tokens have to have the same groups, so that the new token is a replacement
+// for each of them.
+func findTokens(ctx context.Context, c client.Reader, opts Options) ([]*corev1.Secret, *utilbootstraptoken.BootstrapToken, error) {
+	secrets, err := utilbootstraptoken.ListSecrets(ctx, c)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var poolGroup string
+	if opts.PoolName != "" {
+		poolGroup, err = bootstraptoken.PoolGroup(opts.Type, opts.PoolName)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var (
+		res         []*corev1.Secret
+		groupSets   = sets.New[string]()
+		poolNames   = sets.New[string]()
+		newest      *utilbootstraptoken.BootstrapToken
+		newestSince time.Time
+	)
+	for i := range secrets {
+		secret := &secrets[i]
+		token, err := utilbootstraptoken.FromSecret(secret)
+		if err != nil {
+			_, _ = fmt.Fprintf(opts.ErrOut, "Skipping secret %s/%s: %v\n", secret.Namespace, secret.Name, err)
+			continue
+		}
+
+		if !bootstraptoken.HasType(token, opts.Type) {
+			continue
+		}
+		if poolGroup != "" && !slices.Contains(token.Groups, poolGroup) {
+			continue
+		}
+
+		res = append(res, secret)
+		groupSets.Insert(strings.Join(sets.List(sets.New(token.Groups...)), ","))
+		poolNames.Insert(bootstraptoken.PoolNamesOfGroups(opts.Type, token.Groups)...)
+		if created := secret.CreationTimestamp.Time; newest == nil || created.After(newestSince) {
+			newest = token
+			newestSince = created
+		}
+	}
+	if newest == nil {
+		if opts.PoolName != "" {
+			return nil, nil, fmt.Errorf("no bootstrap tokens of type %s scoped to pool %s found", opts.Type, opts.PoolName)
+		}
+		return nil, nil, fmt.Errorf("no bootstrap tokens of type %s found", opts.Type)
+	}
+	if groupSets.Len() > 1 {
+		return nil, nil, fmt.Errorf("bootstrap tokens of type %s have different groups (pools %v), "+
+			"specify --pool-name to rotate the tokens of a single pool", opts.Type, sets.List(poolNames))
+	}
+
+	template := &utilbootstraptoken.BootstrapToken{
+		Description: newest.Description,
+		Usages:      newest.Usages,
+		Groups:      newest.Groups,
+	}
+	if newest.Expires != nil {
+		if ttl := newest.Expires.Sub(newestSince).Round(time.Second); ttl > 0 {
+			template = template.WithTTL(ttl)
+		}
+	}
+	return res, template, nil
+}
@@ -0,0 +1,115 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package joincommand
+
+import (
+	"fmt"
+	"io"
+	"text/template"
+
+	"github.com/ironcore-dev/kubectl-ironcore/bootstraptoken"
+	utilbootstraptoken "github.com/ironcore-dev/kubectl-ironcore/utils/bootstraptoken"
+	"github.com/ironcore-dev/kubectl-ironcore/utils/pubkeypin"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	certutil "k8s.io/client-go/util/cert"
+)
+
+// DefaultTemplate is the template used if no template is specified.
+// It discovers the cluster via its cluster-info ConfigMap to write a bootstrap kubeconfig
+// and then starts the component with it.
+const DefaultTemplate = `kubectl ironcore generate bootstrap-kubeconfig \
+  --discovery-server={{ .Server }} \
+  --bootstrap-token={{ .Token }} \
+{{- range .CACertHashes }}
+  --discovery-token-ca-cert-hash={{ . }} \
+{{- else }}
+  --discovery-token-unsafe-skip-ca-verification \
+{{- end }}
+  > bootstrap-kubeconfig
+{{ .Command }} --bootstrap-kubeconfig=bootstrap-kubeconfig
+`
+
+// Data is the data join command templates are rendered with.
+type Data struct {
+	// Type is the type of the token.
+	Type bootstraptoken.Type
+	// Command is the command line of the component registering with tokens of the type.
+	Command string
+	// Server is the address of the API server.
+	Server string
+	// CACertHashes are the hashes of the API server CA certificates in the form 'sha256:<hex>'.
+	CACertHashes []string
+	// Token is the bootstrap token in the form 'id.secret'.
+	Token string
+	// TokenID is the id of the bootstrap token.
+	TokenID string
+	// PoolName is the name of the pool the token is scoped to. It is empty if the token is not scoped to a single pool.
+	PoolName string
+}
+
+// NewData creates the Data for the given flattened bootstrap kubeconfig, token type and token.
+func NewData(
+	bootstrapCfg *clientcmdapi.Config,
+	typ bootstraptoken.Type,
+	token *utilbootstraptoken.BootstrapToken,
+) (*Data, error) {
+	context, ok := bootstrapCfg.Contexts[bootstrapCfg.CurrentContext]
+	if !ok {
+		return nil, fmt.Errorf("context %q not found", bootstrapCfg.CurrentContext)
+	}
+
+	cluster, ok := bootstrapCfg.Clusters[context.Cluster]
+	if !ok {
+		return nil, fmt.Errorf("cluster %q not found", context.Cluster)
+	}
+
+	var caCertHashes []string
+	if len(cluster.CertificateAuthorityData) > 0 {
+		caCerts, err := certutil.ParseCertsPEM(cluster.CertificateAuthorityData)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing certificate authority data: %w", err)
+		}
+
+		for _, caCert := range caCerts {
+			caCertHashes = append(caCertHashes, pubkeypin.Hash(caCert))
+		}
+	}
+
+	var command string
+	if typ != "" {
+		var err error
+		command, err = bootstraptoken.JoinCommand(typ)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var poolName string
+	if poolNames := bootstraptoken.PoolNamesOfGroups(typ, token.Groups); len(poolNames) == 1 {
+		poolName = poolNames[0]
+	}
+
+	return &Data{
+		Type:         typ,
+		Command:      command,
+		Server:       cluster.Server,
+		CACertHashes: caCertHashes,
+		Token:        fmt.Sprintf("%s.%s", token.ID, token.Secret),
+		TokenID:      token.ID,
+		PoolName:     poolName,
+	}, nil
+}
+
+// Render renders the given template with the given data to w.
+func Render(w io.Writer, text string, data *Data) error {
+	tmpl, err := template.New("join-command").Parse(text)
+	if err != nil {
+		return fmt.Errorf("error parsing join command template: %w", err)
+	}
+
+	if err := tmpl.Execute(w, data); err != nil {
+		return fmt.Errorf("error rendering join command: %w", err)
+	}
+	return nil
+}
@@ -5,10 +5,14 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/signal"
+	"syscall"
 
+	"github.com/go-logr/logr"
 	"github.com/go-logr/zapr"
+	"github.com/ironcore-dev/kubectl-ironcore/bootstraptoken"
 	kubectlironcore "github.com/ironcore-dev/kubectl-ironcore/cmd/kubectl-ironcore"
 	"go.uber.org/zap"
 )
@@ -22,8 +26,16 @@ func main() {
 
 	setupLog := zapr.NewLogger(zapLog)
 
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	// Commands not dealing with token types must keep working with a broken token types file,
+	// so the error is only reported and the built-in types are used.
+	overrideBuiltinTypes := bootstraptoken.OverrideBuiltinTypesFromArgs(os.Args[1:])
+	if err := bootstraptoken.LoadDefaultTypesFile(overrideBuiltinTypes); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: only the built-in token types are available: %v\n", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
+	ctx = logr.NewContext(ctx, setupLog)
 
 	if err := kubectlironcore.DefaultCommand().ExecuteContext(ctx); err != nil {
 		setupLog.Error(err, "Error running command")
@@ -0,0 +1,266 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package poollet
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/ironcore-dev/kubectl-ironcore/bootstraptoken"
+	"github.com/ironcore-dev/kubectl-ironcore/version"
+)
+
+const (
+	KindDeployment = "Deployment"
+	KindDaemonSet  = "DaemonSet"
+)
+
+const (
+	// BootstrapKubeconfigDir is the directory the bootstrap kubeconfig secret is mounted at in the poollet container.
+	BootstrapKubeconfigDir = "/etc/poollet/bootstrap"
+	// BootstrapKubeconfigKey is the key of the bootstrap kubeconfig in its secret.
+	BootstrapKubeconfigKey = "kubeconfig"
+)
+
+// Config describes how the poollet of a bootstrap token type is deployed.
+type Config struct {
+	// Kind is the kind of workload the poollet runs as, either KindDeployment or KindDaemonSet.
+	Kind string
+	// ImageRepository is the container image repository of the poollet. The image is tagged with the release
+	// version of the plugin, see DefaultImage.
+	ImageRepository string
+	// Command is the command of the poollet container. If empty, the image entrypoint is used.
+	Command []string
+	// PoolNameFlag is the flag the poollet expects the name of its pool with.
+	// If empty, the pool name is not passed to the poollet.
+	PoolNameFlag string
+}
+
+var configByType = map[bootstraptoken.Type]Config{
+	bootstraptoken.MachinePoolType: {
+		Kind:            KindDeployment,
+		ImageRepository: "ghcr.io/ironcore-dev/ironcore-machinepoollet",
+		PoolNameFlag:    "--machine-pool-name",
+	},
+	bootstraptoken.VolumePoolType: {
+		Kind:            KindDeployment,
+		ImageRepository: "ghcr.io/ironcore-dev/ironcore-volumepoollet",
+		PoolNameFlag:    "--volume-pool-name",
+	},
+	bootstraptoken.BucketPoolType: {
+		Kind:            KindDeployment,
+		ImageRepository: "ghcr.io/ironcore-dev/ironcore-bucketpoollet",
+		PoolNameFlag:    "--bucket-pool-name",
+	},
+	bootstraptoken.APINetletType: {
+		Kind:            KindDeployment,
+		ImageRepository: "ghcr.io/ironcore-dev/ironcore-net-apinetlet",
+	},
+	bootstraptoken.MetalnetletType: {
+		Kind:            KindDaemonSet,
+		ImageRepository: "ghcr.io/ironcore-dev/ironcore-net-metalnetlet",
+	},
+}
+
+// DefaultConfig returns the default Config for the poollet of the given type.
+// Types without a well-known poollet return an empty Config with KindDeployment.
+func DefaultConfig(typ bootstraptoken.Type) Config {
+	if cfg, ok := configByType[typ]; ok {
+		return cfg
+	}
+	return Config{Kind: KindDeployment}
+}
+
+// DefaultImage returns the image repository of the given Config tagged with the release version of the plugin.
+func DefaultImage(cfg Config) (string, error) {
+	if cfg.ImageRepository == "" {
+		return "", fmt.Errorf("no default image repository")
+	}
+	tag, ok := version.ReleaseVersion()
+	if !ok {
+		return "", fmt.Errorf("plugin version %s is not a release version", version.Version())
+	}
+	return cfg.ImageRepository + ":" + tag, nil
+}
+
+// DefaultTemplate is the template used if no template is specified.
+// It renders the ServiceAccount, bootstrap kubeconfig Secret, leader election RBAC and workload of a poollet.
+const DefaultTemplate = `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: {{ .Name }}
+  namespace: {{ .Namespace }}
+  labels:
+    app.kubernetes.io/name: {{ .Name }}
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: {{ .SecretName }}
+  namespace: {{ .Namespace }}
+  labels:
+    app.kubernetes.io/name: {{ .Name }}
+type: Opaque
+stringData:
+  {{ .SecretKey }}: |
+{{ indent 4 .BootstrapKubeconfig }}
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: {{ .Name }}-leader-election
+  namespace: {{ .Namespace }}
+  labels:
+    app.kubernetes.io/name: {{ .Name }}
+rules:
+- apiGroups:
+  - coordination.k8s.io
+  resources:
+  - leases
+  verbs:
+  - get
+  - list
+  - watch
+  - create
+  - update
+  - patch
+  - delete
+- apiGroups:
+  - ""
+  resources:
+  - events
+  verbs:
+  - create
+  - patch
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: {{ .Name }}-leader-election
+  namespace: {{ .Namespace }}
+  labels:
+    app.kubernetes.io/name: {{ .Name }}
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: {{ .Name }}-leader-election
+subjects:
+- kind: ServiceAccount
+  name: {{ .Name }}
+  namespace: {{ .Namespace }}
+---
+apiVersion: apps/v1
+kind: {{ .Kind }}
+metadata:
+  name: {{ .Name }}
+  namespace: {{ .Namespace }}
+  labels:
+    app.kubernetes.io/name: {{ .Name }}
+spec:
+{{- if eq .Kind "Deployment" }}
+  replicas: 1
+{{- end }}
+  selector:
+    matchLabels:
+      app.kubernetes.io/name: {{ .Name }}
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/name: {{ .Name }}
+    spec:
+      serviceAccountName: {{ .Name }}
+      containers:
+      - name: poollet
+        image: {{ quote .Image }}
+{{- if .Command }}
+        command:
+{{- range .Command }}
+        - {{ quote . }}
+{{- end }}
+{{- end }}
+        args:
+{{- range .Args }}
+        - {{ quote . }}
+{{- end }}
+        volumeMounts:
+        - name: bootstrap-kubeconfig
+          mountPath: {{ .BootstrapKubeconfigDir }}
+          readOnly: true
+      volumes:
+      - name: bootstrap-kubeconfig
+        secret:
+          secretName: {{ .SecretName }}
+`
+
+// Data is the data poollet templates are rendered with.
+type Data struct {
+	// Type is the type of the bootstrap token of the poollet.
+	Type bootstraptoken.Type
+	// PoolName is the name of the pool the poollet registers.
+	PoolName string
+	// Name is the name of the poollet resources.
+	Name string
+	// Namespace is the namespace of the poollet resources.
+	Namespace string
+	// SecretName is the name of the secret containing the bootstrap kubeconfig.
+	SecretName string
+	// SecretKey is the key of the bootstrap kubeconfig in its secret.
+	SecretKey string
+	// BootstrapKubeconfig is the bootstrap kubeconfig of the poollet.
+	BootstrapKubeconfig string
+	// BootstrapKubeconfigDir is the directory the bootstrap kubeconfig secret is mounted at.
+	BootstrapKubeconfigDir string
+	// TokenID is the id of the bootstrap token of the poollet.
+	TokenID string
+
+	// Kind is the kind of workload the poollet runs as.
+	Kind string
+	// Image is the container image of the poollet.
+	Image string
+	// Command is the command of the poollet container.
+	Command []string
+	// Args are the arguments of the poollet container.
+	Args []string
+}
+
+// DefaultArgs returns the arguments for a poollet of the given Config registering the given pool
+// using the bootstrap kubeconfig mounted at BootstrapKubeconfigDir.
+func DefaultArgs(cfg Config, poolName string) []string {
+	args := []string{fmt.Sprintf("--bootstrap-kubeconfig=%s/%s", BootstrapKubeconfigDir, BootstrapKubeconfigKey)}
+	if cfg.PoolNameFlag != "" {
+		args = append(args, fmt.Sprintf("%s=%s", cfg.PoolNameFlag, poolName))
+	}
+	return args
+}
+
+var funcs = template.FuncMap{
+	"indent": indent,
+	"quote":  strconv.Quote,
+}
+
+func indent(spaces int, s string) string {
+	prefix := strings.Repeat(" ", spaces)
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = prefix + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Render renders the given template text with the given data to w.
+// Besides the builtin functions, templates may use 'indent <spaces> <string>' and 'quote <string>'.
+func Render(w io.Writer, text string, data *Data) error {
+	tmpl, err := template.New("poollet").Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return fmt.Errorf("error parsing poollet template: %w", err)
+	}
+
+	if err := tmpl.Execute(w, data); err != nil {
+		return fmt.Errorf("error rendering poollet template: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"context"
+
+	"github.com/ironcore-dev/kubectl-ironcore/api"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Object server-side applies the given object, honoring the given dry run strategy.
+func Object(ctx context.Context, c client.Client, obj client.Object, dryRun cmdutil.DryRunStrategy) error {
+	patchOpts := []client.PatchOption{client.ForceOwnership, api.FieldOwner}
+	if dryRun == cmdutil.DryRunServer {
+		patchOpts = append(patchOpts, client.DryRunAll)
+	}
+	return c.Patch(ctx, obj, client.Apply, patchOpts...)
+}
+
+// DryRunSuffix returns the suffix to append to status messages for the given dry run strategy.
+func DryRunSuffix(dryRun cmdutil.DryRunStrategy) string {
+	switch dryRun {
+	case cmdutil.DryRunClient:
+		return " (dry run)"
+	case cmdutil.DryRunServer:
+		return " (server dry run)"
+	default:
+		return ""
+	}
+}
@@ -31,6 +31,7 @@ const (
 var (
 	idRegexp     = regexp.MustCompile(api.BootstrapTokenIDPattern)
 	secretRegexp = regexp.MustCompile(SecretPattern)
+	tokenRegexp  = regexp.MustCompile(api.BootstrapTokenPattern)
 )
 
 func ValidateID(id string) error {
@@ -55,6 +56,28 @@ func IsValidSecret(secret string) bool {
 	return ValidateSecret(secret) == nil
 }
 
+// ParseToken parses a token of the form 'id.secret' into its id and secret.
+func ParseToken(token string) (id, secret string, err error) {
+	match := tokenRegexp.FindStringSubmatch(token)
+	if match == nil {
+		return "", "", fmt.Errorf("bootstrap token is invalid (must match string %s)", api.BootstrapTokenPattern)
+	}
+	return match[1], match[2], nil
+}
+
+// ParseIDOrToken parses the given string either as token id or as token of the form 'id.secret'
+// and returns the token id.
+func ParseIDOrToken(s string) (string, error) {
+	if IsValidID(s) {
+		return s, nil
+	}
+	if id, _, err := ParseToken(s); err == nil {
+		return id, nil
+	}
+	return "", fmt.Errorf("%q is neither a valid bootstrap token id (%s) nor a valid bootstrap token (%s)",
+		s, api.BootstrapTokenIDPattern, api.BootstrapTokenPattern)
+}
+
 // randBytes returns a random string consisting of the characters in
 // validBootstrapTokenChars, with the length customized by the parameter
 func randBytes(length int) (string, error) {
@@ -0,0 +1,28 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstraptoken
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SecretTypeField is the field selector key to filter secrets by their type.
+const SecretTypeField = "type"
+
+// ListSecrets lists all bootstrap token secrets in the metav1.NamespaceSystem namespace.
+func ListSecrets(ctx context.Context, c client.Reader) ([]corev1.Secret, error) {
+	secretList := &corev1.SecretList{}
+	if err := c.List(ctx, secretList,
+		client.InNamespace(metav1.NamespaceSystem),
+		client.MatchingFields{SecretTypeField: string(corev1.SecretTypeBootstrapToken)},
+	); err != nil {
+		return nil, fmt.Errorf("error listing bootstrap token secrets: %w", err)
+	}
+	return secretList.Items, nil
+}
@@ -15,7 +15,10 @@ type BootstrapToken struct {
 }
 
 func (t *BootstrapToken) WithTTL(ttl time.Duration) *BootstrapToken {
-	expires := time.Now().Add(ttl)
+	return t.WithExpires(time.Now().Add(ttl))
+}
+
+func (t *BootstrapToken) WithExpires(expires time.Time) *BootstrapToken {
 	return &BootstrapToken{
 		ID:          t.ID,
 		Secret:      t.Secret,
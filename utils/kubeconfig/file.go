@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package kubeconfig
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// WriteTempFile writes the given data to a new temporary file next to filename that only its owner can read
+// and returns the name of the temporary file. Renaming it to filename replaces filename including its permissions.
+func WriteTempFile(filename string, data []byte) (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// WriteFile writes the given data to filename, replacing any existing file, so that only its owner can read it.
+func WriteFile(filename string, data []byte) error {
+	tmpFilename, err := WriteTempFile(filename, data)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmpFilename, filename); err != nil {
+		_ = os.Remove(tmpFilename)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package kubeconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		existing bool
+	}{
+		{name: "new file"},
+		{name: "existing world-readable file", existing: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			filename := filepath.Join(dir, "bootstrap.kubeconfig")
+			if tc.existing {
+				if err := os.WriteFile(filename, []byte("old"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := WriteFile(filename, []byte("new")); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			data, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != "new" {
+				t.Errorf("expected data %q, got %q", "new", data)
+			}
+
+			info, err := os.Stat(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if perm := info.Mode().Perm(); perm != 0600 {
+				t.Errorf("expected permissions 0600, got %#o", perm)
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 {
+				t.Errorf("expected only the written file, got %d entries", len(entries))
+			}
+		})
+	}
+}
+
+func TestWriteTempFileError(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "missing", "bootstrap.kubeconfig")
+	if _, err := WriteTempFile(filename, []byte("data")); err == nil {
+		t.Error("expected an error writing to a missing directory")
+	}
+}
@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package kubeconfig
+
+import (
+	"fmt"
+
+	"github.com/ironcore-dev/kubectl-ironcore/bootstrapkubeconfig"
+	utilbootstraptoken "github.com/ironcore-dev/kubectl-ironcore/utils/bootstraptoken"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// StartingConfig returns the starting config of the kubeconfig files of the given getter with its current
+// context set to the given context name. If the context name is empty, the current context is kept.
+func StartingConfig(restClientGetter genericclioptions.RESTClientGetter, contextName string) (*clientcmdapi.Config, error) {
+	startingCfg, err := restClientGetter.ToRawKubeConfigLoader().ConfigAccess().GetStartingConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if contextName != "" {
+		startingCfg.CurrentContext = contextName
+	}
+	return startingCfg, nil
+}
+
+// clientConfig returns the client config of the given getter. If the context name is set, the client config
+// of that context is returned instead, without the overrides of the getter.
+func clientConfig(restClientGetter genericclioptions.RESTClientGetter, contextName string) (clientcmd.ClientConfig, error) {
+	loader := restClientGetter.ToRawKubeConfigLoader()
+	if contextName == "" {
+		return loader, nil
+	}
+
+	rawCfg, err := loader.RawConfig()
+	if err != nil {
+		return nil, err
+	}
+	return clientcmd.NewDefaultClientConfig(rawCfg, &clientcmd.ConfigOverrides{CurrentContext: contextName}), nil
+}
+
+// NewClient creates a client for the cluster of the given getter, honoring its overrides.
+// If the context name is set, the cluster of that context is used instead.
+func NewClient(restClientGetter genericclioptions.RESTClientGetter, contextName string) (client.Client, error) {
+	clientCfg, err := clientConfig(restClientGetter, contextName)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := clientCfg.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return client.New(cfg, client.Options{})
+}
+
+// Namespace returns the namespace of the given getter, honoring its overrides.
+// If the context name is set, the namespace of that context is returned instead.
+func Namespace(restClientGetter genericclioptions.RESTClientGetter, contextName string) (string, error) {
+	clientCfg, err := clientConfig(restClientGetter, contextName)
+	if err != nil {
+		return "", err
+	}
+
+	namespace, _, err := clientCfg.Namespace()
+	if err != nil {
+		return "", fmt.Errorf("error determining namespace of kubeconfig context: %w", err)
+	}
+	return namespace, nil
+}
+
+// GenerateBootstrap generates a flattened bootstrap kubeconfig for the given token from the given starting config.
+func GenerateBootstrap(startingCfg *clientcmdapi.Config, token *utilbootstraptoken.BootstrapToken) (*clientcmdapi.Config, error) {
+	apiCfg, err := bootstrapkubeconfig.Generate(startingCfg, token)
+	if err != nil {
+		return nil, fmt.Errorf("error generating bootstrap kubeconfig: %w", err)
+	}
+
+	if err := clientcmdapi.FlattenConfig(apiCfg); err != nil {
+		return nil, err
+	}
+	return apiCfg, nil
+}
@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package pubkeypin
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// FormatSHA256 is the prefix of SHA-256 public key pins.
+const FormatSHA256 = "sha256"
+
+// Hash computes the pin of the given certificate in the form 'sha256:<hex>'.
+// The pin is the SHA-256 hash of the certificate's Subject Public Key Info.
+func Hash(certificate *x509.Certificate) string {
+	spkiHash := sha256.Sum256(certificate.RawSubjectPublicKeyInfo)
+	return FormatSHA256 + ":" + hex.EncodeToString(spkiHash[:])
+}
+
+// Set is a set of allowed public key pins.
+type Set struct {
+	sha256Hashes sets.Set[string]
+}
+
+// NewSet creates a new, empty Set.
+func NewSet() *Set {
+	return &Set{sha256Hashes: sets.New[string]()}
+}
+
+// Allow validates and adds the given pins of the form 'sha256:<hex>' to the set.
+func (s *Set) Allow(pins ...string) error {
+	for _, pin := range pins {
+		format, value, ok := strings.Cut(pin, ":")
+		if !ok {
+			return fmt.Errorf("invalid public key pin %q (must be of the form '%s:<hex>')", pin, FormatSHA256)
+		}
+		if strings.ToLower(format) != FormatSHA256 {
+			return fmt.Errorf("unsupported public key pin format %q (only %s is supported)", format, FormatSHA256)
+		}
+
+		value = strings.ToLower(value)
+		if decoded, err := hex.DecodeString(value); err != nil || len(decoded) != sha256.Size {
+			return fmt.Errorf("invalid public key pin %q (must be a hex-encoded %s hash)", pin, FormatSHA256)
+		}
+
+		s.sha256Hashes.Insert(value)
+	}
+	return nil
+}
+
+// Empty reports whether the set contains no pins.
+func (s *Set) Empty() bool {
+	return s.sha256Hashes.Len() == 0
+}
+
+// CheckAny verifies that at least one of the given certificates matches a pin of the set.
+func (s *Set) CheckAny(certificates []*x509.Certificate) error {
+	var hashes []string
+	for _, certificate := range certificates {
+		hash := Hash(certificate)
+		if s.sha256Hashes.Has(strings.TrimPrefix(hash, FormatSHA256+":")) {
+			return nil
+		}
+
+		hashes = append(hashes, hash)
+	}
+	return fmt.Errorf("none of the public keys %v are pinned", hashes)
+}
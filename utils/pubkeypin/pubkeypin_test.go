@@ -0,0 +1,164 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package pubkeypin
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newCertificate(t *testing.T, commonName string) *x509.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("error creating certificate: %v", err)
+	}
+
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("error parsing certificate: %v", err)
+	}
+	return certificate
+}
+
+func TestAllow(t *testing.T) {
+	validHex := strings.Repeat("ab", 32)
+
+	for _, tc := range []struct {
+		name        string
+		pins        []string
+		expectError bool
+		expectEmpty bool
+	}{
+		{
+			name:        "no pins",
+			expectEmpty: true,
+		},
+		{
+			name: "valid pin",
+			pins: []string{"sha256:" + validHex},
+		},
+		{
+			name: "upper case pin",
+			pins: []string{"SHA256:" + strings.ToUpper(validHex)},
+		},
+		{
+			name:        "missing format",
+			pins:        []string{validHex},
+			expectError: true,
+		},
+		{
+			name:        "unsupported format",
+			pins:        []string{"md5:" + validHex},
+			expectError: true,
+		},
+		{
+			name:        "invalid hex",
+			pins:        []string{"sha256:" + strings.Repeat("zz", 32)},
+			expectError: true,
+		},
+		{
+			name:        "invalid length",
+			pins:        []string{"sha256:" + validHex[:62]},
+			expectError: true,
+		},
+		{
+			name:        "invalid pin after valid pin",
+			pins:        []string{"sha256:" + validHex, "sha256:"},
+			expectError: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewSet()
+			err := s.Allow(tc.pins...)
+			if tc.expectError {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if s.Empty() != tc.expectEmpty {
+				t.Errorf("expected empty to be %t", tc.expectEmpty)
+			}
+		})
+	}
+}
+
+func TestCheckAny(t *testing.T) {
+	pinned := newCertificate(t, "pinned")
+	other := newCertificate(t, "other")
+
+	s := NewSet()
+	if err := s.Allow(strings.ToUpper(Hash(pinned))); err != nil {
+		t.Fatalf("error allowing pin: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name         string
+		certificates []*x509.Certificate
+		expectError  bool
+	}{
+		{
+			name:         "pinned certificate",
+			certificates: []*x509.Certificate{pinned},
+		},
+		{
+			name:         "pinned certificate after other certificate",
+			certificates: []*x509.Certificate{other, pinned},
+		},
+		{
+			name:         "other certificate",
+			certificates: []*x509.Certificate{other},
+			expectError:  true,
+		},
+		{
+			name:        "no certificates",
+			expectError: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := s.CheckAny(tc.certificates)
+			if tc.expectError && err == nil {
+				t.Error("expected an error")
+			}
+			if !tc.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestHash(t *testing.T) {
+	certificate := newCertificate(t, "test")
+
+	hash := Hash(certificate)
+	if !strings.HasPrefix(hash, FormatSHA256+":") {
+		t.Errorf("expected hash %q to start with %s:", hash, FormatSHA256)
+	}
+	if err := NewSet().Allow(hash); err != nil {
+		t.Errorf("expected hash %q to be a valid pin: %v", hash, err)
+	}
+}
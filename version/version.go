@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"regexp"
 	"runtime/debug"
 )
 
@@ -18,6 +19,18 @@ func Version() string {
 	return info.Main.Version
 }
 
+var releaseVersionRegexp = regexp.MustCompile(`^v\d+\.\d+\.\d+$`)
+
+// ReleaseVersion returns the Version if it is a release version of the form vX.Y.Z.
+// Development builds, pseudo-versions and versions of modified sources are no release versions.
+func ReleaseVersion() (string, bool) {
+	v := Version()
+	if !releaseVersionRegexp.MatchString(v) {
+		return "", false
+	}
+	return v, true
+}
+
 func FPrint(w io.Writer) {
 	_, _ = fmt.Fprintf(w, "Version: %s\n", Version())
 }